@@ -2,10 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 
@@ -17,11 +17,13 @@ import (
 
 	"github.com/gtngzlv/gophkeeper-server/internal/app"
 	"github.com/gtngzlv/gophkeeper-server/internal/config"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
 	"github.com/gtngzlv/gophkeeper-server/internal/logger"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background())
+	// Контекст отменяется только при остановке, фоновые задачи работают до неё
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
 	defer cancel()
 	cfg := config.MustLoad()
 	log := logger.MustSetup(cfg.Env)
@@ -32,34 +34,34 @@ func main() {
 	}
 
 	go application.GRPCSrv.MustRun()
-	go runRest(cfg)
+	go runRest(cfg, application.Keys)
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-		syscall.SIGHUP,
-		syscall.SIGKILL,
-		syscall.SIGSEGV)
-	<-stop
+	<-ctx.Done()
 
-	application.GRPCSrv.Stop(ctx)
+	application.GRPCSrv.Stop(context.Background())
 	log.Info("Gracefully stopped")
 }
 
-func runRest(cfg *config.Config) {
+func runRest(cfg *config.Config, keys *core.KeySet) {
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	mux := runtime.NewServeMux()
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
-	err := pb.RegisterGophkeeperHandlerFromEndpoint(ctx, mux, "localhost:"+string(cfg.GRPC.Port), opts)
+	err := pb.RegisterGophkeeperHandlerFromEndpoint(ctx, mux, fmt.Sprintf("localhost:%d", cfg.GRPC.Port), opts)
 	if err != nil {
 		panic(err)
 	}
 	mux.HandlePath("GET", "/gophkeeper.swagger.json", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
 		http.FileServer(http.Dir("internal/proto")).ServeHTTP(w, r)
 	})
+	mux.HandlePath("GET", "/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=300")
+		if err := json.NewEncoder(w).Encode(keys.JWKS()); err != nil {
+			log.Printf("failed to write jwks: %v", err)
+		}
+	})
 	log.Printf("rest listening on port %v", cfg.REST.Port)
 	if err := http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", cfg.REST.Port), mux); err != nil {
 		panic(err)
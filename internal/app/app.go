@@ -2,16 +2,29 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 
 	grpcapp "github.com/gtngzlv/gophkeeper-server/internal/app/grpc"
+	jobsapp "github.com/gtngzlv/gophkeeper-server/internal/app/jobs"
 	"github.com/gtngzlv/gophkeeper-server/internal/config"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/attempts"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/broker"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/keyring"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/mailer"
 	"github.com/gtngzlv/gophkeeper-server/internal/repository"
+	"github.com/gtngzlv/gophkeeper-server/internal/repository/fs"
+	"github.com/gtngzlv/gophkeeper-server/internal/repository/s3"
 	"github.com/gtngzlv/gophkeeper-server/internal/services/gophkeeper"
 )
 
+const ephemeralKeyID = "ephemeral"
+
 type App struct {
 	GRPCSrv *grpcapp.App
+	Keys    *core.KeySet
 }
 
 func NewApp(
@@ -21,12 +34,120 @@ func NewApp(
 	const op = "App.New"
 	log = log.With(slog.String("op", op))
 
+	keys, err := loadKeys(log, cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	mail, err := newMailer(log, cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
 	repo := repository.New(ctx, log, cfg)
 
-	srv := gophkeeper.New(log, repo, cfg.TokenTTL)
-	grpcApp := grpcapp.New(log, srv, cfg)
+	blobs, err := newBlobStore(repo, cfg.Blob)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	limits := gophkeeper.LoginLimiters{
+		Account: attempts.New(cfg.LoginLimit.AccountAttempts, cfg.LoginLimit.BaseDelay, cfg.LoginLimit.MaxDelay),
+		IP:      attempts.New(cfg.LoginLimit.IPAttempts, cfg.LoginLimit.BaseDelay, cfg.LoginLimit.MaxDelay),
+	}
+
+	srv := gophkeeper.New(log, repo, blobs, keyring.New(), keys, mail, broker.New(), limits,
+		cfg.TokenTTL, cfg.RefreshTokenTTL, cfg.Mail.VerificationTTL)
+	grpcApp := grpcapp.New(log, srv, keys, cfg)
+
+	startJobs(ctx, log, srv, cfg)
 
 	return &App{
 		GRPCSrv: grpcApp,
+		Keys:    keys,
 	}, nil
 }
+
+// startJobs runs background cleanup of version history, trash, abandoned uploads and purged file content
+// until ctx is done.
+func startJobs(ctx context.Context, log *slog.Logger, srv *gophkeeper.Service, cfg *config.Config) {
+	if cfg.History.KeepVersions == 0 && cfg.History.MaxAge == 0 {
+		log.Info("version history is kept forever, pruner disabled")
+	} else {
+		prune := func(ctx context.Context) (int64, error) {
+			return srv.PruneVersions(ctx, cfg.History.KeepVersions, cfg.History.MaxAge)
+		}
+		go jobsapp.New(log, "prune versions", cfg.History.PruneInterval, prune).Run(ctx)
+	}
+
+	if cfg.Trash.Retention == 0 {
+		log.Info("trash is kept forever, purger disabled")
+	} else {
+		purge := func(ctx context.Context) (int64, error) {
+			return srv.PurgeTrash(ctx, cfg.Trash.Retention)
+		}
+		go jobsapp.New(log, "purge trash", cfg.Trash.PurgeInterval, purge).Run(ctx)
+	}
+
+	go jobsapp.New(log, "remove expired uploads", cfg.Blob.CleanupInterval, srv.RemoveExpiredUploads).Run(ctx)
+	go jobsapp.New(log, "remove purged blobs", cfg.Blob.CleanupInterval, srv.RemovePurgedBlobs).Run(ctx)
+}
+
+// loadKeys reads token signing keys from config. Without configured keys a random one is generated,
+// access tokens then become invalid after restart and clients have to use refresh tokens.
+func loadKeys(log *slog.Logger, cfg config.JWTConfig) (*core.KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		log.Warn("jwt keys are not configured, using ephemeral signing key")
+		key, err := core.GenerateKey(ephemeralKeyID)
+		if err != nil {
+			return nil, err
+		}
+		return core.NewKeySet(ephemeralKeyID, key)
+	}
+
+	keys := make([]core.Key, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		key, err := core.LoadKey(k.ID, k.Algorithm, k.File)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return core.NewKeySet(cfg.SigningKeyID, keys...)
+}
+
+func newMailer(log *slog.Logger, cfg config.MailConfig) (gophkeeper.IMailer, error) {
+	switch cfg.Mailer {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil, errors.New("smtp host is not configured")
+		}
+		return mailer.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From), nil
+	case "file":
+		if cfg.File == "" {
+			return nil, errors.New("mail file is not configured")
+		}
+		return mailer.NewFile(cfg.File), nil
+	case "log":
+		return mailer.NewLog(log), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Mailer)
+	}
+}
+
+// newBlobStore returns the blob store selected by config. The postgres store keeps chunks in the repository database.
+func newBlobStore(repo *repository.Repository, cfg config.BlobConfig) (repository.IBlobStore, error) {
+	switch cfg.Store {
+	case "postgres":
+		return repo, nil
+	case "fs":
+		if cfg.Dir == "" {
+			return nil, errors.New("blob dir is not configured")
+		}
+		return fs.New(cfg.Dir)
+	case "s3":
+		return s3.New(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.Store)
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 
 	"github.com/gtngzlv/gophkeeper-server/internal/config"
 	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
 	"github.com/gtngzlv/gophkeeper-server/internal/grpc/gophkeeper"
+	"github.com/gtngzlv/gophkeeper-server/internal/grpc/interceptors"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
 )
 
 type App struct {
@@ -23,13 +30,90 @@ type App struct {
 }
 
 type IGophkeeperService interface {
-	Register(ctx context.Context, email string, password string) (userID int64, err error)
-	Login(ctx context.Context, email string, password string) (token string, err error)
-	SaveData(ctx context.Context, data models.PersonalData) error
+	Register(ctx context.Context, email string, password string, withRecovery bool) (userID int64, recoveryCode string, err error)
+	Login(ctx context.Context, email string, password string, device models.Device) (models.Tokens, error)
+	VerifyEmail(ctx context.Context, code string) error
+	SendVerificationEmail(ctx context.Context) error
+	LoginTwoFactor(ctx context.Context, challengeToken string, code string, device models.Device) (models.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string) (models.Tokens, error)
+	Logout(ctx context.Context) error
+	ListSessions(ctx context.Context) ([]models.Session, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	CreateAccessToken(ctx context.Context, name string, scope models.AccessScope, ttl time.Duration) (string, models.AccessToken, error)
+	ListAccessTokens(ctx context.Context) ([]models.AccessToken, error)
+	RevokeAccessToken(ctx context.Context, id string) error
+	SaveData(ctx context.Context, secrets []models.Secret, attrs models.Attributes) ([]int64, error)
+	GetData(ctx context.Context, id int64) (models.Record, error)
+	ListData(ctx context.Context, opts models.ListOptions) ([]models.Record, int64, error)
+	Sync(ctx context.Context, since int64, limit int) (models.Changes, error)
+	SubscribeChanges(ctx context.Context) (<-chan struct{}, func(), error)
+	UpdateData(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error)
+	DeleteData(ctx context.Context, id int64, version int64) error
+	ListRecordVersions(ctx context.Context, id int64) ([]models.Record, error)
+	RestoreRecordVersion(ctx context.Context, id int64, version int64, expectedVersion int64) (models.Record, error)
+	ListTrash(ctx context.Context) ([]models.Record, error)
+	RestoreFromTrash(ctx context.Context, id int64) (models.Record, error)
+	CreateFolder(ctx context.Context, name string, parentID int64) (models.Folder, error)
+	ListFolder(ctx context.Context, id int64) (models.FolderContents, error)
+	RenameFolder(ctx context.Context, id int64, name string) (models.Folder, error)
+	MoveFolder(ctx context.Context, id int64, parentID int64) (models.Folder, error)
+	DeleteFolder(ctx context.Context, id int64) error
+	MoveRecord(ctx context.Context, id int64, folderID int64) (models.Record, error)
+	ShareRecord(ctx context.Context, id int64, recipientEmail string, permission models.SharePermission) (models.Share, error)
+	RevokeShare(ctx context.Context, id int64, recipientEmail string) error
+	ListSharedWithMe(ctx context.Context) ([]models.SharedRecord, error)
+	UpdateSharedRecord(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error)
+	CreateUpload(ctx context.Context, name string, size int64, checksum string, attrs models.Attributes) (models.Upload, error)
+	GetUpload(ctx context.Context, id string) (models.Upload, error)
+	WriteChunk(ctx context.Context, id string, offset int64, chunk []byte) (models.Upload, error)
+	CompleteUpload(ctx context.Context, id string) (models.Record, error)
+	ReadChunk(ctx context.Context, id int64, index int) ([]byte, error)
+	ChangePassword(ctx context.Context, oldPassword string, newPassword string) (models.Tokens, error)
+	RecoverAccount(ctx context.Context, email string, recoveryCode string, newPassword string) (newRecoveryCode string, err error)
+	DeleteAccount(ctx context.Context, password string) error
+	Enable2FA(ctx context.Context) (models.TOTPEnrollment, error)
+	Confirm2FA(ctx context.Context, code string) error
+	Disable2FA(ctx context.Context, code string) error
+	ValidateSession(ctx context.Context, claims *core.Claims) error
+	ValidateAccessToken(ctx context.Context, token string) (*core.Claims, error)
 }
 
-func New(log *slog.Logger, srv IGophkeeperService, cfg *config.Config) *App {
-	grpcServer := grpc.NewServer()
+func New(log *slog.Logger, srv IGophkeeperService, tokens interceptors.ITokenParser, cfg *config.Config) *App {
+	auth := interceptors.NewAuth(tokens, srv,
+		pb.Gophkeeper_Register_FullMethodName,
+		pb.Gophkeeper_Login_FullMethodName,
+		pb.Gophkeeper_LoginTwoFactor_FullMethodName,
+		pb.Gophkeeper_VerifyEmail_FullMethodName,
+		pb.Gophkeeper_RefreshToken_FullMethodName,
+		pb.Gophkeeper_RecoverAccount_FullMethodName,
+		reflectionv1.ServerReflection_ServerReflectionInfo_FullMethodName,
+		reflectionv1alpha.ServerReflection_ServerReflectionInfo_FullMethodName,
+	).AllowAccessTokens(srv,
+		[]string{
+			pb.Gophkeeper_GetData_FullMethodName,
+			pb.Gophkeeper_ListData_FullMethodName,
+			pb.Gophkeeper_DownloadBlob_FullMethodName,
+			pb.Gophkeeper_ListRecordVersions_FullMethodName,
+			pb.Gophkeeper_ListTrash_FullMethodName,
+		},
+		[]string{
+			pb.Gophkeeper_SaveData_FullMethodName,
+			pb.Gophkeeper_UpdateData_FullMethodName,
+			pb.Gophkeeper_DeleteData_FullMethodName,
+			pb.Gophkeeper_RestoreRecordVersion_FullMethodName,
+			pb.Gophkeeper_RestoreFromTrash_FullMethodName,
+			pb.Gophkeeper_CreateUpload_FullMethodName,
+			pb.Gophkeeper_GetUpload_FullMethodName,
+			pb.Gophkeeper_UploadBlob_FullMethodName,
+		},
+	)
+
+	clientIP := interceptors.NewClientIP()
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(clientIP.Unary(), auth.Unary()),
+		grpc.ChainStreamInterceptor(clientIP.Stream(), auth.Stream()),
+	)
 
 	gophkeeper.Register(grpcServer, srv)
 
@@ -56,10 +140,11 @@ func (a *App) Run() error {
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	a.listener = listener
 
 	reflection.Register(a.grpcServer)
 	if err := a.grpcServer.Serve(listener); err != nil {
-		log.Error("can't start gRPC server" + err.Error())
+		log.Error("can't start gRPC server", logger.Err(err))
 		return err
 	}
 	return nil
@@ -70,7 +155,7 @@ func (a *App) Stop(ctx context.Context) {
 	log := a.log.With(
 		slog.String("op", op),
 		slog.Int("port", a.config.GRPC.Port))
-	log.InfoContext(ctx, "stopping gRPC server", a.listener.Addr().String())
+	log.InfoContext(ctx, "stopping gRPC server")
 
 	a.grpcServer.GracefulStop()
 }
@@ -0,0 +1,59 @@
+package jobsapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// Job does one round of background cleanup and returns the number of removed items.
+type Job func(ctx context.Context) (int64, error)
+
+// App runs a cleanup job on start and then every interval.
+type App struct {
+	log      *slog.Logger
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+func New(log *slog.Logger, name string, interval time.Duration, job Job) *App {
+	return &App{
+		log:      log,
+		name:     name,
+		interval: interval,
+		job:      job,
+	}
+}
+
+// Run runs the job until ctx is done.
+func (a *App) Run(ctx context.Context) {
+	const op = "jobsapp.Run"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("job", a.name))
+
+	if a.interval <= 0 {
+		log.Error("job interval must be positive, job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		removed, err := a.job(ctx)
+		if err != nil {
+			log.Error("job failed", logger.Err(err))
+		} else if removed > 0 {
+			log.Info("job finished", slog.Int64("removed", removed))
+		}
+
+		// Если тик и отмена пришли одновременно, select выбирает случайно, поэтому ctx проверяется в условии цикла
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package jobsapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_RunTicksUntilCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int64
+	ticked := make(chan struct{}, 10)
+	job := func(ctx context.Context) (int64, error) {
+		assert.NoError(t, ctx.Err())
+		if calls.Add(1)%2 == 0 {
+			// Ошибка одного прохода не останавливает задачу
+			return 0, errors.New("temporary failure")
+		}
+		ticked <- struct{}{}
+		return 1, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		New(slog.New(slog.NewTextHandler(io.Discard, nil)), "test", 5*time.Millisecond, job).Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ticked:
+		case <-time.After(time.Second):
+			t.Fatalf("job ran %d times, expected it to keep ticking", calls.Load())
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	stopped := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load(), "job ran after cancel")
+}
+
+func TestApp_RunDisabledWithoutInterval(t *testing.T) {
+	var calls atomic.Int64
+	job := func(context.Context) (int64, error) {
+		calls.Add(1)
+		return 0, nil
+	}
+
+	New(slog.New(slog.NewTextHandler(io.Discard, nil)), "test", 0, job).Run(context.Background())
+	assert.Zero(t, calls.Load())
+}
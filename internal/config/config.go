@@ -9,11 +9,18 @@ import (
 )
 
 type Config struct {
-	Env              string        `yaml:"env"  env-default:"local"`
-	DBConnectionPath string        `yaml:"db_connection_path" env-required:"true"`
-	TokenTTL         time.Duration `yaml:"token_ttl" env-default:"1h"`
-	GRPC             GrpcConfig    `yaml:"grpc"`
-	REST             RestConfig    `yaml:"rest"`
+	Env              string           `yaml:"env"  env-default:"local"`
+	DBConnectionPath string           `yaml:"db_connection_path" env-required:"true"`
+	TokenTTL         time.Duration    `yaml:"token_ttl" env-default:"1h"`
+	RefreshTokenTTL  time.Duration    `yaml:"refresh_token_ttl" env-default:"720h"`
+	GRPC             GrpcConfig       `yaml:"grpc"`
+	REST             RestConfig       `yaml:"rest"`
+	JWT              JWTConfig        `yaml:"jwt"`
+	LoginLimit       LoginLimitConfig `yaml:"login_limit"`
+	Mail             MailConfig       `yaml:"mail"`
+	Blob             BlobConfig       `yaml:"blob"`
+	History          HistoryConfig    `yaml:"history"`
+	Trash            TrashConfig      `yaml:"trash"`
 }
 
 func MustLoad() *Config {
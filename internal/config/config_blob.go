@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// BlobConfig selects where content of file records is kept: "postgres" (large objects),
+// "fs" (files in Dir) or "s3" (any S3-compatible object storage).
+// Abandoned uploads and content of purged records and deleted accounts are removed every CleanupInterval.
+type BlobConfig struct {
+	Store           string        `yaml:"store" env-default:"postgres"`
+	Dir             string        `yaml:"dir"`
+	S3              S3Config      `yaml:"s3"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"`
+}
+
+type S3Config struct {
+	// Endpoint is the base URL of the storage, e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000.
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region" env-default:"us-east-1"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
+}
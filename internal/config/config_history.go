@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+// HistoryConfig sets retention of previous record versions. Zero KeepVersions or MaxAge disables the limit.
+type HistoryConfig struct {
+	KeepVersions  int           `yaml:"keep_versions" env-default:"20"`
+	MaxAge        time.Duration `yaml:"max_age" env-default:"2160h"`
+	PruneInterval time.Duration `yaml:"prune_interval" env-default:"1h"`
+}
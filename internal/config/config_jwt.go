@@ -0,0 +1,14 @@
+package config
+
+type JWTConfig struct {
+	SigningKeyID string         `yaml:"signing_key_id"`
+	Keys         []JWTKeyConfig `yaml:"keys"`
+}
+
+// JWTKeyConfig describes a key file: PEM for EdDSA and RS256, raw secret for HS256.
+// A public PEM is enough for keys that only verify tokens after rotation.
+type JWTKeyConfig struct {
+	ID        string `yaml:"id"`
+	Algorithm string `yaml:"alg"`
+	File      string `yaml:"file"`
+}
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// LoginLimitConfig sets free failed login attempts per account and per IP,
+// every next failure blocks them for base_delay doubled with each failure, up to max_delay.
+// The IP limit is much higher than the account one, many users may share an address behind NAT.
+type LoginLimitConfig struct {
+	AccountAttempts int           `yaml:"account_attempts" env-default:"5"`
+	IPAttempts      int           `yaml:"ip_attempts" env-default:"100"`
+	BaseDelay       time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay        time.Duration `yaml:"max_delay" env-default:"15m"`
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// MailConfig selects how emails are delivered: "smtp", "file" (JSON lines appended to file) or "log".
+type MailConfig struct {
+	Mailer          string        `yaml:"mailer" env-default:"log"`
+	From            string        `yaml:"from" env-default:"gophkeeper@localhost"`
+	VerificationTTL time.Duration `yaml:"verification_ttl" env-default:"24h"`
+	File            string        `yaml:"file"`
+	SMTP            SMTPConfig    `yaml:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+}
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+// TrashConfig sets how long deleted records stay in the trash. Zero Retention disables purging.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
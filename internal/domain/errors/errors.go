@@ -1,12 +1,75 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrFailedGetUserID    = errors.New("failed to get userID from context")
-	ErrFailedSaveData     = errors.New("failed to saved data")
-	ErrFailedInsertData   = errors.New("failed to insert data")
+	ErrUserExists          = errors.New("user already exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrFailedGetUserID     = errors.New("failed to get userID from context")
+	ErrFailedSaveData      = errors.New("failed to saved data")
+	ErrFailedInsertData    = errors.New("failed to insert data")
+	ErrDataNotFound        = errors.New("data not found")
+	ErrUnknownSecretKind   = errors.New("unknown secret kind")
+	ErrSessionKeyNotFound  = errors.New("session key not found")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalidTOTPCode     = errors.New("invalid one-time code")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorDisabled   = errors.New("two-factor authentication is not enabled")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrAccountLocked       = errors.New("account temporarily locked")
+	ErrAccessTokenNotFound = errors.New("access token not found")
+	ErrEmailVerified       = errors.New("email already verified")
+	ErrVersionConflict     = errors.New("record was changed concurrently")
+	ErrDataVersionNotFound = errors.New("record version not found")
+	ErrUploadNotFound      = errors.New("upload not found")
+	ErrUploadOffset        = errors.New("chunk does not continue the upload")
+	ErrInvalidChunk        = errors.New("chunk size does not match the upload")
+	ErrUploadIncomplete    = errors.New("upload is not complete")
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrChunkNotFound       = errors.New("blob chunk not found")
+	ErrFileRecord          = errors.New("file records are changed by uploads only")
+	ErrFolderNotFound      = errors.New("folder not found")
+	ErrFolderNotEmpty      = errors.New("folder is not empty")
+	ErrFolderCycle         = errors.New("folder can not be moved into itself")
+	ErrRecipientNotFound   = errors.New("recipient not found")
+	ErrRecipientNoKeys     = errors.New("recipient has no sharing keys yet")
+	ErrShareSelf           = errors.New("record can not be shared with its owner")
+	ErrShareNotFound       = errors.New("share not found")
+	ErrShareReadOnly       = errors.New("record is shared read-only")
+	ErrShareFile           = errors.New("file records can not be shared")
 )
+
+// RetryError reports that the operation may be retried after RetryAfter.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError reports that the record was changed since the version the client has seen.
+// Current is the server copy of the record, it is empty when it could not be loaded.
+type ConflictError struct {
+	Current models.Record
+}
+
+func (e *ConflictError) Error() string {
+	return ErrVersionConflict.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AccessScope limits what a personal access token may do.
+type AccessScope struct {
+	ReadOnly bool
+	// Tag restricts the token to records having this tag, empty means all records.
+	Tag string
+}
+
+// AccessToken is a long-lived personal token for automation, it is used instead of a login session.
+type AccessToken struct {
+	ID         string
+	UserID     int64
+	Name       string
+	SecretHash []byte
+	WrappedKey []byte
+	Scope      AccessScope
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Active reports whether the token may still be used at the moment now.
+func (t AccessToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+const (
+	AuditAccountLocked = "account_locked"
+	AuditIPBlocked     = "ip_blocked"
+	AuditRecordShared  = "record_shared"
+	AuditShareRevoked  = "share_revoked"
+	// AuditAccountDeleted is written without user and email, the account can not be identified by it.
+	AuditAccountDeleted = "account_deleted"
+)
+
+// AuditEvent is a security relevant event kept for investigation.
+type AuditEvent struct {
+	UserID  int64
+	Event   string
+	IP      string
+	Details string
+}
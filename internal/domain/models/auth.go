@@ -1,9 +1,25 @@
 package models
 
+import "time"
+
 type User struct {
 	ID            int64
 	Email         string
 	PassHash      []byte
 	SecretKeyHash string
 	EncryptedKey  []byte
+	RecoveryKey   []byte
+	TOTPSecret    []byte
+	TOTPEnabled   bool
+	TOTPLastStep  int64
+	// EmailVerifiedAt is nil until the email is confirmed.
+	EmailVerifiedAt *time.Time
+	// Keys are empty for users registered before sharing, until their next login.
+	Keys KeyPair
+}
+
+// TOTPEnrollment is shown to the user to add the account to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
 }
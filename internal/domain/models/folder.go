@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FolderData is a stored folder with its name encrypted by the folder data key.
+// Zero ParentID marks a top-level folder.
+type FolderData struct {
+	ID         int64
+	ParentID   int64
+	Name       []byte
+	WrappedKey []byte
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Folder is a stored folder with its name decrypted.
+type Folder struct {
+	ID        int64
+	ParentID  int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// FolderContents lists a folder with its child folders and records.
+// Folder is empty for the top level.
+type FolderContents struct {
+	Folder  Folder
+	Folders []Folder
+	Records []Record
+}
@@ -1,9 +1,75 @@
 package models
 
+import "time"
+
 type PersonalData struct {
 	PData []Data
 }
 
+// Attributes are searchable metadata and tags of a record.
+type Attributes struct {
+	Metadata map[string]string
+	Tags     []string
+}
+
+// Data is a stored record of any secret kind with its payload serialized and encrypted.
+// Payload and metadata values are encrypted with the record data key, which is stored wrapped
+// by the owner secret key. Empty WrappedKey marks a legacy plaintext record.
 type Data struct {
-	Value string
+	ID         int64
+	Kind       SecretKind
+	Payload    []byte
+	WrappedKey []byte
+	Attributes
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Revision of the last change, see Tombstone.
+	Revision int64
+	// Version is incremented by every update of the record.
+	Version int64
+	// BlobID is the key prefix of file content in the blob store, empty for other kinds.
+	BlobID string
+	// FolderID is the folder of the record, zero for records outside folders.
+	FolderID int64
+	// DeletedAt is set while the record is in the trash.
+	DeletedAt *time.Time
+}
+
+// Record is a stored record with its payload decoded.
+type Record struct {
+	ID     int64
+	Secret Secret
+	Attributes
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Revision  int64
+	Version   int64
+	FolderID  int64
+	DeletedAt *time.Time
+}
+
+// Tombstone marks a deleted record for clients syncing changes.
+// Revisions of records and tombstones share one per-user sequence growing with every write.
+type Tombstone struct {
+	DataID    int64
+	Revision  int64
+	DeletedAt time.Time
+}
+
+// Changes is a page of records changed and deleted after a revision.
+// Revision is the cursor to request the next page with, HasMore is set when it is not the last one.
+type Changes struct {
+	Records  []Record
+	Deleted  []Tombstone
+	Revision int64
+	HasMore  bool
+}
+
+// ListOptions describes a keyset page of user records ordered by ID.
+// Non-empty Tag and MetadataKey narrow the page down to records having them.
+type ListOptions struct {
+	AfterID     int64
+	Limit       int
+	Tag         string
+	MetadataKey string
 }
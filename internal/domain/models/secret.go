@@ -0,0 +1,64 @@
+package models
+
+type SecretKind string
+
+const (
+	SecretCredentials SecretKind = "credentials"
+	SecretText        SecretKind = "text"
+	SecretCard        SecretKind = "card"
+	SecretBinary      SecretKind = "binary"
+	// SecretFile records are created by uploads, their content is kept in the blob store.
+	SecretFile SecretKind = "file"
+)
+
+// Secret is a typed payload of a record. Only the field matching Kind is set.
+type Secret struct {
+	Kind        SecretKind
+	Credentials *Credentials
+	Text        *TextNote
+	Card        *BankCard
+	Binary      *BinaryData
+	File        *FileRef
+}
+
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type TextNote struct {
+	Text string `json:"text"`
+}
+
+type BankCard struct {
+	Number string `json:"number"`
+	Expiry string `json:"expiry"`
+	CVV    string `json:"cvv"`
+	Holder string `json:"holder"`
+}
+
+type BinaryData struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// FileRef describes content of a file record stored in the blob store as encrypted chunks
+// of ChunkSize bytes, the last one may be shorter. Chunks are kept under Data.BlobID.
+type FileRef struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	ChunkSize int    `json:"chunk_size"`
+}
+
+// Chunks returns the number of stored chunks.
+func (f FileRef) Chunks() int {
+	return chunkCount(f.Size, f.ChunkSize)
+}
+
+func chunkCount(size int64, chunkSize int) int {
+	if chunkSize <= 0 {
+		return 0
+	}
+	return int((size + int64(chunkSize) - 1) / int64(chunkSize))
+}
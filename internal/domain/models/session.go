@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Session is a login of a user, the access token is bound to it by ID and renewed with a refresh token.
+type Session struct {
+	ID           string
+	UserID       int64
+	RefreshHash  []byte
+	PreviousHash []byte
+	WrappedKey   []byte
+	Device       Device
+	CreatedAt    time.Time
+	LastSeenAt   time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+}
+
+// Device describes the client a session is opened from.
+type Device struct {
+	Name          string
+	ClientVersion string
+	IP            string
+	UserAgent     string
+}
+
+// Active reports whether the session may still be used at the moment now.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Tokens is a pair issued on login and on every refresh.
+// When a second factor is required login returns only ChallengeToken.
+type Tokens struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
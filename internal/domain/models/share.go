@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+type SharePermission string
+
+const (
+	ShareRead  SharePermission = "read"
+	ShareWrite SharePermission = "write"
+)
+
+// KeyPair is the X25519 keypair of a user, the private key is encrypted with the user secret key.
+type KeyPair struct {
+	PublicKey  []byte
+	PrivateKey []byte
+}
+
+// Share gives the recipient access to a record of the owner.
+// WrappedKey is the record data key wrapped for the recipient public key.
+type Share struct {
+	DataID         int64
+	OwnerID        int64
+	OwnerEmail     string
+	RecipientID    int64
+	RecipientEmail string
+	WrappedKey     []byte
+	Permission     SharePermission
+	CreatedAt      time.Time
+}
+
+// SharedData is a stored record shared with the current user.
+type SharedData struct {
+	Share
+	Data Data
+}
+
+// SharedRecord is a record shared with the current user with its payload decoded.
+type SharedRecord struct {
+	Share
+	Record Record
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Upload is an unfinished upload of a file record. Chunks are written to the blob store under
+// the upload ID, after the last one the file record is created and the upload is removed.
+type Upload struct {
+	ID     string
+	UserID int64
+	Size   int64
+	// WrappedKey is the data key of the future record wrapped by the owner secret key.
+	WrappedKey []byte
+	// Meta holds name, expected checksum and attributes of the file encrypted with the data key.
+	Meta []byte
+	// Received is the number of bytes stored, the next chunk starts at this offset.
+	Received int64
+	// HashState is the serialized SHA-256 state of received bytes.
+	HashState []byte
+	ChunkSize int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Chunks returns the number of chunks stored so far.
+func (u Upload) Chunks() int {
+	return chunkCount(u.Received, u.ChunkSize)
+}
@@ -0,0 +1,109 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+const (
+	maxAccessTokenNameLen = 128
+	maxAccessTokenTTL     = 365 * 24 * time.Hour
+)
+
+func (s *serverAPI) CreateAccessToken(ctx context.Context, in *pb.CreateAccessTokenRequest) (*pb.CreateAccessTokenResponse, error) {
+	if err := validateCreateAccessToken(in); err != nil {
+		return nil, err
+	}
+
+	scope := models.AccessScope{
+		ReadOnly: in.GetReadOnly(),
+		Tag:      in.GetTag(),
+	}
+	token, created, err := s.service.CreateAccessToken(ctx, in.GetName(), scope, in.GetTtl().AsDuration())
+	if err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) || errors.Is(err, customerr.ErrSessionKeyNotFound) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		return nil, status.Error(codes.Internal, "failed to create access token")
+	}
+
+	return &pb.CreateAccessTokenResponse{
+		Token:       token,
+		AccessToken: accessTokenToPB(created),
+	}, nil
+}
+
+func (s *serverAPI) ListAccessTokens(ctx context.Context, _ *pb.ListAccessTokensRequest) (*pb.ListAccessTokensResponse, error) {
+	tokens, err := s.service.ListAccessTokens(ctx)
+	if err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		return nil, status.Error(codes.Internal, "failed to list access tokens")
+	}
+
+	resp := &pb.ListAccessTokensResponse{AccessTokens: make([]*pb.AccessToken, 0, len(tokens))}
+	for _, v := range tokens {
+		resp.AccessTokens = append(resp.AccessTokens, accessTokenToPB(v))
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeAccessToken(ctx context.Context, in *pb.RevokeAccessTokenRequest) (*pb.RevokeAccessTokenResponse, error) {
+	if in.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "access token id is empty")
+	}
+
+	if err := s.service.RevokeAccessToken(ctx, in.GetId()); err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		if errors.Is(err, customerr.ErrAccessTokenNotFound) {
+			return nil, status.Error(codes.NotFound, "access token not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke access token")
+	}
+	return &pb.RevokeAccessTokenResponse{}, nil
+}
+
+func accessTokenToPB(t models.AccessToken) *pb.AccessToken {
+	token := &pb.AccessToken{
+		Id:        t.ID,
+		Name:      t.Name,
+		ReadOnly:  t.Scope.ReadOnly,
+		Tag:       t.Scope.Tag,
+		CreatedAt: timestamppb.New(t.CreatedAt),
+		ExpiresAt: timestamppb.New(t.ExpiresAt),
+	}
+	if t.LastUsedAt != nil {
+		token.LastUsedAt = timestamppb.New(*t.LastUsedAt)
+	}
+	return token
+}
+
+func validateCreateAccessToken(in *pb.CreateAccessTokenRequest) error {
+	if in.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "name is empty")
+	}
+	if len(in.GetName()) > maxAccessTokenNameLen {
+		return status.Error(codes.InvalidArgument, "name is too long")
+	}
+	if in.Ttl != nil {
+		if err := in.GetTtl().CheckValid(); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid ttl")
+		}
+		if ttl := in.GetTtl().AsDuration(); ttl <= 0 || ttl > maxAccessTokenTTL {
+			return status.Error(codes.InvalidArgument, "ttl must be positive and not longer than a year")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package gophkeeper
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+const (
+	maxFileSize     = 10 << 30
+	maxFileNameLen  = 255
+	sha256HexLength = 64
+)
+
+func (s *serverAPI) CreateUpload(ctx context.Context, in *pb.CreateUploadRequest) (*pb.CreateUploadResponse, error) {
+	if err := validateCreateUpload(in); err != nil {
+		return nil, err
+	}
+
+	attrs, err := pbAttributesToDomain(in.GetMetadata(), in.GetTags())
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.service.CreateUpload(ctx, in.GetName(), in.GetSize(), in.GetSha256(), attrs)
+	if err != nil {
+		return nil, dataError(err, "failed to create upload")
+	}
+	return &pb.CreateUploadResponse{Upload: domainToPbUpload(upload)}, nil
+}
+
+func (s *serverAPI) GetUpload(ctx context.Context, in *pb.GetUploadRequest) (*pb.GetUploadResponse, error) {
+	if in.GetUploadId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "upload id is empty")
+	}
+
+	upload, err := s.service.GetUpload(ctx, in.GetUploadId())
+	if err != nil {
+		return nil, dataError(err, "failed to get upload")
+	}
+	return &pb.GetUploadResponse{Upload: domainToPbUpload(upload)}, nil
+}
+
+// UploadBlob stores chunks of the upload in order. Every chunk is saved as soon as it is received,
+// so after a broken stream the client continues from the received offset of GetUpload.
+// The file record is created when the stream ends with all chunks received.
+func (s *serverAPI) UploadBlob(stream pb.Gophkeeper_UploadBlobServer) error {
+	ctx := stream.Context()
+
+	var uploadID string
+	var upload models.Upload
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case uploadID == "":
+			if in.GetUploadId() == "" {
+				return status.Error(codes.InvalidArgument, "upload id is empty")
+			}
+			uploadID = in.GetUploadId()
+		case in.GetUploadId() != "" && in.GetUploadId() != uploadID:
+			return status.Error(codes.InvalidArgument, "upload id changed")
+		}
+		// Первое сообщение может содержать только идентификатор
+		if len(in.GetData()) == 0 {
+			continue
+		}
+
+		upload, err = s.service.WriteChunk(ctx, uploadID, in.GetOffset(), in.GetData())
+		if err != nil {
+			return dataError(err, "failed to upload chunk")
+		}
+	}
+
+	if uploadID == "" {
+		return status.Error(codes.InvalidArgument, "upload id is empty")
+	}
+	if upload.ID == "" {
+		var err error
+		if upload, err = s.service.GetUpload(ctx, uploadID); err != nil {
+			return dataError(err, "failed to get upload")
+		}
+	}
+
+	resp := &pb.UploadBlobResponse{Upload: domainToPbUpload(upload)}
+	if upload.Received == upload.Size {
+		record, err := s.service.CompleteUpload(ctx, uploadID)
+		if err != nil {
+			return dataError(err, "failed to complete upload")
+		}
+		resp.Record = domainToPbRecord(record)
+	}
+	return stream.SendAndClose(resp)
+}
+
+// DownloadBlob sends file info first and then content of the file record starting at offset.
+func (s *serverAPI) DownloadBlob(in *pb.DownloadBlobRequest, stream pb.Gophkeeper_DownloadBlobServer) error {
+	if in.GetId() <= 0 {
+		return status.Error(codes.InvalidArgument, "id is empty")
+	}
+	ctx := stream.Context()
+
+	record, err := s.service.GetData(ctx, in.GetId())
+	if err != nil {
+		return dataError(err, "failed to get data")
+	}
+	file := record.Secret.File
+	if record.Secret.Kind != models.SecretFile || file == nil {
+		return status.Error(codes.FailedPrecondition, "record is not a file")
+	}
+	offset := in.GetOffset()
+	if offset < 0 || offset > file.Size {
+		return status.Error(codes.OutOfRange, "offset is out of file")
+	}
+
+	if err = stream.Send(&pb.DownloadBlobResponse{File: domainToPbFileInfo(file), Offset: offset}); err != nil {
+		return err
+	}
+
+	for index := int(offset / int64(file.ChunkSize)); index < file.Chunks(); index++ {
+		chunk, err := s.service.ReadChunk(ctx, in.GetId(), index)
+		if err != nil {
+			return dataError(err, "failed to read file")
+		}
+
+		start := int64(index) * int64(file.ChunkSize)
+		if offset > start {
+			chunk = chunk[offset-start:]
+			start = offset
+		}
+		if err = stream.Send(&pb.DownloadBlobResponse{Offset: start, Data: chunk}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCreateUpload(in *pb.CreateUploadRequest) error {
+	if in.GetName() == "" || len(in.GetName()) > maxFileNameLen {
+		return status.Error(codes.InvalidArgument, "invalid file name")
+	}
+	if in.GetSize() <= 0 {
+		return status.Error(codes.InvalidArgument, "file is empty")
+	}
+	if in.GetSize() > maxFileSize {
+		return status.Error(codes.InvalidArgument, "file is too large")
+	}
+	if _, err := hex.DecodeString(in.GetSha256()); err != nil || len(in.GetSha256()) != sha256HexLength {
+		return status.Error(codes.InvalidArgument, "invalid sha256 checksum")
+	}
+	return nil
+}
+
+func domainToPbUpload(upload models.Upload) *pb.Upload {
+	return &pb.Upload{
+		UploadId:  upload.ID,
+		Size:      upload.Size,
+		Received:  upload.Received,
+		ChunkSize: int32(upload.ChunkSize),
+		ExpiresAt: timestamppb.New(upload.ExpiresAt),
+	}
+}
+
+func domainToPbFileInfo(file *models.FileRef) *pb.FileInfo {
+	return &pb.FileInfo{
+		Name:   file.Name,
+		Size:   file.Size,
+		Sha256: file.SHA256,
+	}
+}
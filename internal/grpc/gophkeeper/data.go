@@ -0,0 +1,330 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+
+	maxAttributes     = 32
+	maxAttributeLen   = 64
+	maxMetadataValLen = 1024
+)
+
+func (s *serverAPI) SaveData(ctx context.Context, in *pb.SaveDataRequest) (*pb.SaveDataResponse, error) {
+	if len(in.GetData()) == 0 && len(in.GetSecrets()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty request")
+	}
+	secrets, err := pbDataToDomain(in)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := pbAttributesToDomain(in.GetMetadata(), in.GetTags())
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.service.SaveData(ctx, secrets, attrs)
+	if err != nil {
+		return nil, dataError(err, "failed to save data")
+	}
+	return &pb.SaveDataResponse{Ids: ids}, nil
+}
+
+func (s *serverAPI) GetData(ctx context.Context, in *pb.GetDataRequest) (*pb.GetDataResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+
+	data, err := s.service.GetData(ctx, in.GetId())
+	if err != nil {
+		return nil, dataError(err, "failed to get data")
+	}
+	return &pb.GetDataResponse{Record: domainToPbRecord(data)}, nil
+}
+
+func (s *serverAPI) ListData(ctx context.Context, in *pb.ListDataRequest) (*pb.ListDataResponse, error) {
+	opts, err := pbListOptionsToDomain(in)
+	if err != nil {
+		return nil, err
+	}
+
+	data, next, err := s.service.ListData(ctx, opts)
+	if err != nil {
+		return nil, dataError(err, "failed to list data")
+	}
+
+	resp := &pb.ListDataResponse{
+		Records: make([]*pb.Record, 0, len(data)),
+	}
+	for _, v := range data {
+		resp.Records = append(resp.Records, domainToPbRecord(v))
+	}
+	if next > 0 {
+		resp.NextPageToken = strconv.FormatInt(next, 10)
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) Sync(ctx context.Context, in *pb.SyncRequest) (*pb.SyncResponse, error) {
+	if in.GetSinceRevision() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "revision is negative")
+	}
+	limit, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.service.Sync(ctx, in.GetSinceRevision(), limit)
+	if err != nil {
+		return nil, dataError(err, "failed to sync data")
+	}
+
+	resp := &pb.SyncResponse{
+		Records:  make([]*pb.Record, 0, len(changes.Records)),
+		Deleted:  make([]*pb.DeletedRecord, 0, len(changes.Deleted)),
+		Revision: changes.Revision,
+		HasMore:  changes.HasMore,
+	}
+	for _, v := range changes.Records {
+		resp.Records = append(resp.Records, domainToPbRecord(v))
+	}
+	for _, v := range changes.Deleted {
+		resp.Deleted = append(resp.Deleted, domainToPbDeleted(v))
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) UpdateData(ctx context.Context, in *pb.UpdateDataRequest) (*pb.UpdateDataResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetExpectedVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected version is empty")
+	}
+	secret, err := pbSecretToDomain(in.GetSecret())
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := pbAttributesToDomain(in.GetMetadata(), in.GetTags())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.service.UpdateData(ctx, in.GetId(), in.GetExpectedVersion(), secret, attrs)
+	if err != nil {
+		return nil, dataError(err, "failed to update data")
+	}
+	return &pb.UpdateDataResponse{Record: domainToPbRecord(data)}, nil
+}
+
+func (s *serverAPI) DeleteData(ctx context.Context, in *pb.DeleteDataRequest) (*pb.DeleteDataResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+
+	if in.GetExpectedVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected version is empty")
+	}
+
+	if err := s.service.DeleteData(ctx, in.GetId(), in.GetExpectedVersion()); err != nil {
+		return nil, dataError(err, "failed to delete data")
+	}
+	return &pb.DeleteDataResponse{}, nil
+}
+
+// dataError maps service errors of data methods to gRPC statuses.
+// Version conflict is reported with the current record in the status details.
+func dataError(err error, msg string) error {
+	var conflict *customerr.ConflictError
+	if errors.As(err, &conflict) {
+		st, detailsErr := status.New(codes.Aborted, "record was changed, merge with the current version").
+			WithDetails(domainToPbRecord(conflict.Current))
+		if detailsErr != nil {
+			return status.Error(codes.Aborted, "record was changed")
+		}
+		return st.Err()
+	}
+
+	switch {
+	case errors.Is(err, customerr.ErrVersionConflict):
+		return status.Error(codes.Aborted, "record was changed")
+	case errors.Is(err, customerr.ErrFailedGetUserID):
+		return status.Error(codes.Unauthenticated, "not logged in")
+	case errors.Is(err, customerr.ErrSessionKeyNotFound):
+		return status.Error(codes.Unauthenticated, "session expired, login again")
+	case errors.Is(err, customerr.ErrDataNotFound):
+		return status.Error(codes.NotFound, "data not found")
+	case errors.Is(err, customerr.ErrDataVersionNotFound):
+		return status.Error(codes.NotFound, "record version not found")
+	case errors.Is(err, customerr.ErrUnknownSecretKind):
+		return status.Error(codes.InvalidArgument, "unknown secret kind")
+	case errors.Is(err, customerr.ErrFileRecord):
+		return status.Error(codes.FailedPrecondition, "file records are changed by uploads only")
+	case errors.Is(err, customerr.ErrUploadNotFound):
+		return status.Error(codes.NotFound, "upload not found")
+	case errors.Is(err, customerr.ErrUploadOffset):
+		return status.Error(codes.FailedPrecondition, "chunk does not continue the upload, check upload progress")
+	case errors.Is(err, customerr.ErrInvalidChunk):
+		return status.Error(codes.InvalidArgument, "chunk size does not match the upload")
+	case errors.Is(err, customerr.ErrUploadIncomplete):
+		return status.Error(codes.FailedPrecondition, "upload is not complete")
+	case errors.Is(err, customerr.ErrChecksumMismatch):
+		return status.Error(codes.InvalidArgument, "checksum mismatch, upload discarded")
+	case errors.Is(err, customerr.ErrFolderNotFound):
+		return status.Error(codes.NotFound, "folder not found")
+	case errors.Is(err, customerr.ErrFolderNotEmpty):
+		return status.Error(codes.FailedPrecondition, "folder is not empty")
+	case errors.Is(err, customerr.ErrFolderCycle):
+		return status.Error(codes.InvalidArgument, "folder can not be moved into itself")
+	case errors.Is(err, customerr.ErrRecipientNotFound):
+		return status.Error(codes.NotFound, "recipient not found")
+	case errors.Is(err, customerr.ErrRecipientNoKeys):
+		return status.Error(codes.FailedPrecondition, "recipient has to log in once before records can be shared")
+	case errors.Is(err, customerr.ErrShareSelf):
+		return status.Error(codes.InvalidArgument, "record can not be shared with its owner")
+	case errors.Is(err, customerr.ErrShareNotFound):
+		return status.Error(codes.NotFound, "share not found")
+	case errors.Is(err, customerr.ErrShareReadOnly):
+		return status.Error(codes.PermissionDenied, "record is shared read-only")
+	case errors.Is(err, customerr.ErrShareFile):
+		return status.Error(codes.FailedPrecondition, "file records can not be shared")
+	case errors.Is(err, customerr.ErrChunkNotFound):
+		return status.Error(codes.DataLoss, "file content is missing")
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
+
+func pbDataToDomain(in *pb.SaveDataRequest) ([]models.Secret, error) {
+	secrets := make([]models.Secret, 0, len(in.GetData())+len(in.GetSecrets()))
+	for _, v := range in.GetData() {
+		if v == "" {
+			return nil, status.Error(codes.InvalidArgument, "data is empty")
+		}
+		secrets = append(secrets, models.Secret{
+			Kind: models.SecretText,
+			Text: &models.TextNote{Text: v},
+		})
+	}
+	for _, v := range in.GetSecrets() {
+		secret, err := pbSecretToDomain(v)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// pbAttributesToDomain validates metadata and tags and drops duplicate tags.
+func pbAttributesToDomain(metadata map[string]string, tags []string) (models.Attributes, error) {
+	if len(metadata) > maxAttributes {
+		return models.Attributes{}, status.Error(codes.InvalidArgument, "too many metadata entries")
+	}
+	if len(tags) > maxAttributes {
+		return models.Attributes{}, status.Error(codes.InvalidArgument, "too many tags")
+	}
+
+	var attrs models.Attributes
+	for k, v := range metadata {
+		if k == "" || len(k) > maxAttributeLen {
+			return models.Attributes{}, status.Error(codes.InvalidArgument, "invalid metadata key")
+		}
+		if len(v) > maxMetadataValLen {
+			return models.Attributes{}, status.Error(codes.InvalidArgument, "metadata value is too long")
+		}
+		if attrs.Metadata == nil {
+			attrs.Metadata = make(map[string]string, len(metadata))
+		}
+		attrs.Metadata[k] = v
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || len(tag) > maxAttributeLen {
+			return models.Attributes{}, status.Error(codes.InvalidArgument, "invalid tag")
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		attrs.Tags = append(attrs.Tags, tag)
+	}
+	return attrs, nil
+}
+
+func pbListOptionsToDomain(in *pb.ListDataRequest) (models.ListOptions, error) {
+	limit, err := pageSize(in.GetPageSize())
+	if err != nil {
+		return models.ListOptions{}, err
+	}
+	opts := models.ListOptions{
+		Limit:       limit,
+		Tag:         strings.TrimSpace(in.GetTag()),
+		MetadataKey: in.GetMetadataKey(),
+	}
+
+	if token := in.GetPageToken(); token != "" {
+		afterID, err := strconv.ParseInt(token, 10, 64)
+		if err != nil || afterID <= 0 {
+			return models.ListOptions{}, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		opts.AfterID = afterID
+	}
+	return opts, nil
+}
+
+func domainToPbDeleted(tombstone models.Tombstone) *pb.DeletedRecord {
+	return &pb.DeletedRecord{
+		Id:        tombstone.DataID,
+		Revision:  tombstone.Revision,
+		DeletedAt: timestamppb.New(tombstone.DeletedAt),
+	}
+}
+
+// pageSize applies the default and the upper bound to the requested page size.
+func pageSize(size int32) (int, error) {
+	switch {
+	case size < 0:
+		return 0, status.Error(codes.InvalidArgument, "page size is negative")
+	case size == 0:
+		return defaultPageSize, nil
+	case size > maxPageSize:
+		return maxPageSize, nil
+	}
+	return int(size), nil
+}
+
+func domainToPbRecord(record models.Record) *pb.Record {
+	res := &pb.Record{
+		Id:        record.ID,
+		Secret:    domainSecretToPb(record.Secret),
+		Metadata:  record.Metadata,
+		Tags:      record.Tags,
+		CreatedAt: timestamppb.New(record.CreatedAt),
+		UpdatedAt: timestamppb.New(record.UpdatedAt),
+		Revision:  record.Revision,
+		Version:   record.Version,
+		FolderId:  record.FolderID,
+	}
+	if record.DeletedAt != nil {
+		res.DeletedAt = timestamppb.New(*record.DeletedAt)
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+package gophkeeper
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+const maxFolderNameLen = 255
+
+func (s *serverAPI) CreateFolder(ctx context.Context, in *pb.CreateFolderRequest) (*pb.CreateFolderResponse, error) {
+	if err := validateFolderName(in.GetName()); err != nil {
+		return nil, err
+	}
+	if in.GetParentId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid parent id")
+	}
+
+	folder, err := s.service.CreateFolder(ctx, in.GetName(), in.GetParentId())
+	if err != nil {
+		return nil, dataError(err, "failed to create folder")
+	}
+	return &pb.CreateFolderResponse{Folder: domainToPbFolder(folder)}, nil
+}
+
+func (s *serverAPI) ListFolder(ctx context.Context, in *pb.ListFolderRequest) (*pb.ListFolderResponse, error) {
+	if in.GetId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	contents, err := s.service.ListFolder(ctx, in.GetId())
+	if err != nil {
+		return nil, dataError(err, "failed to list folder")
+	}
+
+	resp := &pb.ListFolderResponse{
+		Folders: make([]*pb.Folder, 0, len(contents.Folders)),
+		Records: make([]*pb.Record, 0, len(contents.Records)),
+	}
+	if in.GetId() != 0 {
+		resp.Folder = domainToPbFolder(contents.Folder)
+	}
+	for _, v := range contents.Folders {
+		resp.Folders = append(resp.Folders, domainToPbFolder(v))
+	}
+	for _, v := range contents.Records {
+		resp.Records = append(resp.Records, domainToPbRecord(v))
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) RenameFolder(ctx context.Context, in *pb.RenameFolderRequest) (*pb.RenameFolderResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if err := validateFolderName(in.GetName()); err != nil {
+		return nil, err
+	}
+
+	folder, err := s.service.RenameFolder(ctx, in.GetId(), in.GetName())
+	if err != nil {
+		return nil, dataError(err, "failed to rename folder")
+	}
+	return &pb.RenameFolderResponse{Folder: domainToPbFolder(folder)}, nil
+}
+
+func (s *serverAPI) MoveFolder(ctx context.Context, in *pb.MoveFolderRequest) (*pb.MoveFolderResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetParentId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid parent id")
+	}
+
+	folder, err := s.service.MoveFolder(ctx, in.GetId(), in.GetParentId())
+	if err != nil {
+		return nil, dataError(err, "failed to move folder")
+	}
+	return &pb.MoveFolderResponse{Folder: domainToPbFolder(folder)}, nil
+}
+
+func (s *serverAPI) DeleteFolder(ctx context.Context, in *pb.DeleteFolderRequest) (*pb.DeleteFolderResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+
+	if err := s.service.DeleteFolder(ctx, in.GetId()); err != nil {
+		return nil, dataError(err, "failed to delete folder")
+	}
+	return &pb.DeleteFolderResponse{}, nil
+}
+
+func (s *serverAPI) MoveRecord(ctx context.Context, in *pb.MoveRecordRequest) (*pb.MoveRecordResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetFolderId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid folder id")
+	}
+
+	record, err := s.service.MoveRecord(ctx, in.GetId(), in.GetFolderId())
+	if err != nil {
+		return nil, dataError(err, "failed to move record")
+	}
+	return &pb.MoveRecordResponse{Record: domainToPbRecord(record)}, nil
+}
+
+func validateFolderName(name string) error {
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "name is empty")
+	}
+	if len(name) > maxFolderNameLen {
+		return status.Error(codes.InvalidArgument, "name is too long")
+	}
+	return nil
+}
+
+func domainToPbFolder(folder models.Folder) *pb.Folder {
+	return &pb.Folder{
+		Id:        folder.ID,
+		ParentId:  folder.ParentID,
+		Name:      folder.Name,
+		CreatedAt: timestamppb.New(folder.CreatedAt),
+		UpdatedAt: timestamppb.New(folder.UpdatedAt),
+	}
+}
@@ -0,0 +1,113 @@
+package gophkeeper
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/card"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+// pbSecretToDomain validates secret payload and converts it to the domain model.
+func pbSecretToDomain(in *pb.Secret) (models.Secret, error) {
+	switch p := in.GetPayload().(type) {
+	case *pb.Secret_Credentials:
+		if p.Credentials.GetLogin() == "" {
+			return models.Secret{}, status.Error(codes.InvalidArgument, "login is empty")
+		}
+		if p.Credentials.GetPassword() == "" {
+			return models.Secret{}, status.Error(codes.InvalidArgument, "password is empty")
+		}
+		return models.Secret{
+			Kind: models.SecretCredentials,
+			Credentials: &models.Credentials{
+				Login:    p.Credentials.GetLogin(),
+				Password: p.Credentials.GetPassword(),
+			},
+		}, nil
+	case *pb.Secret_Text:
+		if p.Text.GetText() == "" {
+			return models.Secret{}, status.Error(codes.InvalidArgument, "text is empty")
+		}
+		return models.Secret{
+			Kind: models.SecretText,
+			Text: &models.TextNote{Text: p.Text.GetText()},
+		}, nil
+	case *pb.Secret_Card:
+		bankCard, err := validateCard(p.Card)
+		if err != nil {
+			return models.Secret{}, err
+		}
+		return models.Secret{
+			Kind: models.SecretCard,
+			Card: bankCard,
+		}, nil
+	case *pb.Secret_Binary:
+		if len(p.Binary.GetData()) == 0 {
+			return models.Secret{}, status.Error(codes.InvalidArgument, "binary data is empty")
+		}
+		return models.Secret{
+			Kind: models.SecretBinary,
+			Binary: &models.BinaryData{
+				Name: p.Binary.GetName(),
+				Data: p.Binary.GetData(),
+			},
+		}, nil
+	case *pb.Secret_File:
+		return models.Secret{}, status.Error(codes.InvalidArgument, "files are stored with CreateUpload")
+	default:
+		return models.Secret{}, status.Error(codes.InvalidArgument, "secret is empty")
+	}
+}
+
+func validateCard(in *pb.BankCard) (*models.BankCard, error) {
+	number := card.NormalizeNumber(in.GetNumber())
+	if err := card.ValidateNumber(number); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := card.ParseExpiry(in.GetExpiry()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if in.GetCvv() != "" {
+		if err := card.ValidateCVV(in.GetCvv()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	return &models.BankCard{
+		Number: number,
+		Expiry: in.GetExpiry(),
+		CVV:    in.GetCvv(),
+		Holder: in.GetHolder(),
+	}, nil
+}
+
+func domainSecretToPb(secret models.Secret) *pb.Secret {
+	switch secret.Kind {
+	case models.SecretCredentials:
+		return &pb.Secret{Payload: &pb.Secret_Credentials{Credentials: &pb.Credentials{
+			Login:    secret.Credentials.Login,
+			Password: secret.Credentials.Password,
+		}}}
+	case models.SecretText:
+		return &pb.Secret{Payload: &pb.Secret_Text{Text: &pb.TextNote{
+			Text: secret.Text.Text,
+		}}}
+	case models.SecretCard:
+		return &pb.Secret{Payload: &pb.Secret_Card{Card: &pb.BankCard{
+			Number: secret.Card.Number,
+			Expiry: secret.Card.Expiry,
+			Cvv:    secret.Card.CVV,
+			Holder: secret.Card.Holder,
+		}}}
+	case models.SecretBinary:
+		return &pb.Secret{Payload: &pb.Secret_Binary{Binary: &pb.BinaryData{
+			Name: secret.Binary.Name,
+			Data: secret.Binary.Data,
+		}}}
+	case models.SecretFile:
+		return &pb.Secret{Payload: &pb.Secret_File{File: domainToPbFileInfo(secret.File)}}
+	default:
+		return nil
+	}
+}
@@ -3,20 +3,67 @@ package gophkeeper
 import (
 	"context"
 	"errors"
+	"net/mail"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
 	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
 	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
 )
 
 type IGophkeeperService interface {
-	Register(ctx context.Context, email string, password string) (userID int64, err error)
-	Login(ctx context.Context, email string, password string) (token string, err error)
-	SaveData(ctx context.Context, data models.PersonalData) error
+	Register(ctx context.Context, email string, password string, withRecovery bool) (userID int64, recoveryCode string, err error)
+	Login(ctx context.Context, email string, password string, device models.Device) (models.Tokens, error)
+	VerifyEmail(ctx context.Context, code string) error
+	SendVerificationEmail(ctx context.Context) error
+	LoginTwoFactor(ctx context.Context, challengeToken string, code string, device models.Device) (models.Tokens, error)
+	RefreshToken(ctx context.Context, refreshToken string) (models.Tokens, error)
+	Logout(ctx context.Context) error
+	ListSessions(ctx context.Context) ([]models.Session, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	CreateAccessToken(ctx context.Context, name string, scope models.AccessScope, ttl time.Duration) (string, models.AccessToken, error)
+	ListAccessTokens(ctx context.Context) ([]models.AccessToken, error)
+	RevokeAccessToken(ctx context.Context, id string) error
+	SaveData(ctx context.Context, secrets []models.Secret, attrs models.Attributes) ([]int64, error)
+	GetData(ctx context.Context, id int64) (models.Record, error)
+	ListData(ctx context.Context, opts models.ListOptions) ([]models.Record, int64, error)
+	Sync(ctx context.Context, since int64, limit int) (models.Changes, error)
+	SubscribeChanges(ctx context.Context) (<-chan struct{}, func(), error)
+	UpdateData(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error)
+	DeleteData(ctx context.Context, id int64, version int64) error
+	ListRecordVersions(ctx context.Context, id int64) ([]models.Record, error)
+	RestoreRecordVersion(ctx context.Context, id int64, version int64, expectedVersion int64) (models.Record, error)
+	ListTrash(ctx context.Context) ([]models.Record, error)
+	RestoreFromTrash(ctx context.Context, id int64) (models.Record, error)
+	CreateFolder(ctx context.Context, name string, parentID int64) (models.Folder, error)
+	ListFolder(ctx context.Context, id int64) (models.FolderContents, error)
+	RenameFolder(ctx context.Context, id int64, name string) (models.Folder, error)
+	MoveFolder(ctx context.Context, id int64, parentID int64) (models.Folder, error)
+	DeleteFolder(ctx context.Context, id int64) error
+	MoveRecord(ctx context.Context, id int64, folderID int64) (models.Record, error)
+	ShareRecord(ctx context.Context, id int64, recipientEmail string, permission models.SharePermission) (models.Share, error)
+	RevokeShare(ctx context.Context, id int64, recipientEmail string) error
+	ListSharedWithMe(ctx context.Context) ([]models.SharedRecord, error)
+	UpdateSharedRecord(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error)
+	CreateUpload(ctx context.Context, name string, size int64, checksum string, attrs models.Attributes) (models.Upload, error)
+	GetUpload(ctx context.Context, id string) (models.Upload, error)
+	WriteChunk(ctx context.Context, id string, offset int64, chunk []byte) (models.Upload, error)
+	CompleteUpload(ctx context.Context, id string) (models.Record, error)
+	ReadChunk(ctx context.Context, id int64, index int) ([]byte, error)
+	ChangePassword(ctx context.Context, oldPassword string, newPassword string) (models.Tokens, error)
+	RecoverAccount(ctx context.Context, email string, recoveryCode string, newPassword string) (newRecoveryCode string, err error)
+	DeleteAccount(ctx context.Context, password string) error
+	Enable2FA(ctx context.Context) (models.TOTPEnrollment, error)
+	Confirm2FA(ctx context.Context, code string) error
+	Disable2FA(ctx context.Context, code string) error
+	ValidateSession(ctx context.Context, claims *core.Claims) error
 }
 
 type serverAPI struct {
@@ -36,7 +83,7 @@ func (s *serverAPI) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.R
 		return nil, err
 	}
 
-	userID, err := s.service.Register(ctx, in.GetEmail(), in.GetPassword())
+	userID, recoveryCode, err := s.service.Register(ctx, in.GetEmail(), in.GetPassword(), in.GetGenerateRecoveryCode())
 	if err != nil {
 		if errors.Is(err, customerr.ErrUserExists) {
 			return nil, status.Error(codes.AlreadyExists, "user with this email already exist")
@@ -45,7 +92,8 @@ func (s *serverAPI) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.R
 	}
 
 	return &pb.RegisterResponse{
-		UserId: userID,
+		UserId:       userID,
+		RecoveryCode: recoveryCode,
 	}, nil
 }
 
@@ -53,47 +101,132 @@ func (s *serverAPI) Login(ctx context.Context, in *pb.LoginRequest) (*pb.LoginRe
 	if err := validateLogin(in); err != nil {
 		return nil, err
 	}
-	token, err := s.service.Login(ctx, in.GetEmail(), in.GetPassword())
+	tokens, err := s.service.Login(ctx, in.GetEmail(), in.GetPassword(), pbDeviceToDomain(ctx, in.GetDevice()))
 	if err != nil {
 		if errors.Is(err, customerr.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid credentials")
 		}
+		if st, ok := limitStatus(err); ok {
+			return nil, st
+		}
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
 	return &pb.LoginResponse{
-		Token: token,
+		Token:          tokens.AccessToken,
+		RefreshToken:   tokens.RefreshToken,
+		ChallengeToken: tokens.ChallengeToken,
 	}, nil
 }
 
-func (s *serverAPI) SaveData(ctx context.Context, in *pb.SaveDataRequest) (*pb.SaveDataResponse, error) {
-	if len(in.Data) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "empty request")
+func (s *serverAPI) RefreshToken(ctx context.Context, in *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	if in.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is empty")
 	}
-	data, err := pbDataToDomain(in)
+
+	tokens, err := s.service.RefreshToken(ctx, in.GetRefreshToken())
 	if err != nil {
+		if errors.Is(err, customerr.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+		return nil, status.Error(codes.Internal, "failed to refresh token")
+	}
+
+	return &pb.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *serverAPI) Logout(ctx context.Context, _ *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if err := s.service.Logout(ctx); err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		return nil, status.Error(codes.Internal, "failed to logout")
+	}
+	return &pb.LogoutResponse{}, nil
+}
+
+func (s *serverAPI) ChangePassword(ctx context.Context, in *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	if err := validateChangePassword(in); err != nil {
 		return nil, err
 	}
 
-	if err = s.service.SaveData(ctx, data); err != nil {
+	tokens, err := s.service.ChangePassword(ctx, in.GetOldPassword(), in.GetNewPassword())
+	if err != nil {
+		if errors.Is(err, customerr.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid credentials")
+		}
 		if errors.Is(err, customerr.ErrFailedGetUserID) {
 			return nil, status.Error(codes.Unauthenticated, "not logged in")
 		}
-		return nil, status.Error(codes.Internal, "failed to save data")
+		return nil, status.Error(codes.Internal, "failed to change password")
 	}
-	return &pb.SaveDataResponse{}, nil
+
+	return &pb.ChangePasswordResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
 }
 
-func pbDataToDomain(in *pb.SaveDataRequest) (models.PersonalData, error) {
-	var data []models.Data
-	for _, v := range in.GetData() {
-		if v == "" {
-			return models.PersonalData{}, status.Error(codes.InvalidArgument, "data is empty")
+func (s *serverAPI) DeleteAccount(ctx context.Context, in *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	if in.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is empty")
+	}
+
+	if err := s.service.DeleteAccount(ctx, in.GetPassword()); err != nil {
+		if errors.Is(err, customerr.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid credentials")
 		}
-		md := models.Data{Value: v}
-		data = append(data, md)
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		return nil, status.Error(codes.Internal, "failed to delete account")
 	}
-	return models.PersonalData{PData: data}, nil
+	return &pb.DeleteAccountResponse{}, nil
+}
+
+func (s *serverAPI) RecoverAccount(ctx context.Context, in *pb.RecoverAccountRequest) (*pb.RecoverAccountResponse, error) {
+	if err := validateRecoverAccount(in); err != nil {
+		return nil, err
+	}
+
+	recoveryCode, err := s.service.RecoverAccount(ctx, in.GetEmail(), in.GetRecoveryCode(), in.GetNewPassword())
+	if err != nil {
+		if st, ok := limitStatus(err); ok {
+			return nil, st
+		}
+		if errors.Is(err, customerr.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, "invalid email or recovery code")
+		}
+		return nil, status.Error(codes.Internal, "failed to recover account")
+	}
+
+	return &pb.RecoverAccountResponse{
+		RecoveryCode: recoveryCode,
+	}, nil
+}
+
+// limitStatus maps login throttling errors, the delay is passed to the client as RetryInfo.
+func limitStatus(err error) (error, bool) {
+	var st *status.Status
+	switch {
+	case errors.Is(err, customerr.ErrTooManyAttempts):
+		st = status.New(codes.ResourceExhausted, "too many attempts, try again later")
+	case errors.Is(err, customerr.ErrAccountLocked):
+		st = status.New(codes.PermissionDenied, "account temporarily locked, try again later")
+	default:
+		return nil, false
+	}
+
+	var retryErr *customerr.RetryError
+	if errors.As(err, &retryErr) {
+		if detailed, e := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryErr.RetryAfter)}); e == nil {
+			st = detailed
+		}
+	}
+	return st.Err(), true
 }
 
 func validateLogin(in *pb.LoginRequest) error {
@@ -111,9 +244,43 @@ func validateRegister(in *pb.RegisterRequest) error {
 	if in.GetEmail() == "" {
 		return status.Error(codes.InvalidArgument, "email is empty")
 	}
+	// Принимаем только голый адрес, без имени и угловых скобок
+	if addr, err := mail.ParseAddress(in.GetEmail()); err != nil || addr.Address != in.GetEmail() {
+		return status.Error(codes.InvalidArgument, "invalid email")
+	}
 
 	if in.GetPassword() == "" {
 		return status.Error(codes.InvalidArgument, "password is empty")
 	}
 	return nil
 }
+
+func validateChangePassword(in *pb.ChangePasswordRequest) error {
+	if in.GetOldPassword() == "" {
+		return status.Error(codes.InvalidArgument, "old password is empty")
+	}
+
+	if in.GetNewPassword() == "" {
+		return status.Error(codes.InvalidArgument, "new password is empty")
+	}
+
+	if in.GetNewPassword() == in.GetOldPassword() {
+		return status.Error(codes.InvalidArgument, "new password matches the old one")
+	}
+	return nil
+}
+
+func validateRecoverAccount(in *pb.RecoverAccountRequest) error {
+	if in.GetEmail() == "" {
+		return status.Error(codes.InvalidArgument, "email is empty")
+	}
+
+	if in.GetRecoveryCode() == "" {
+		return status.Error(codes.InvalidArgument, "recovery code is empty")
+	}
+
+	if in.GetNewPassword() == "" {
+		return status.Error(codes.InvalidArgument, "new password is empty")
+	}
+	return nil
+}
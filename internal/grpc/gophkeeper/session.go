@@ -0,0 +1,95 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+const maxDeviceFieldLen = 256
+
+// user-agent of REST clients is forwarded by the gateway with its prefix.
+var userAgentHeaders = []string{"grpcgateway-user-agent", "user-agent"}
+
+func (s *serverAPI) ListSessions(ctx context.Context, _ *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	sessions, err := s.service.ListSessions(ctx)
+	if err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		return nil, status.Error(codes.Internal, "failed to list sessions")
+	}
+
+	current := core.GetContextSessionID(ctx)
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.Session, 0, len(sessions))}
+	for _, v := range sessions {
+		resp.Sessions = append(resp.Sessions, &pb.Session{
+			Id:            v.ID,
+			DeviceName:    v.Device.Name,
+			ClientVersion: v.Device.ClientVersion,
+			Ip:            v.Device.IP,
+			UserAgent:     v.Device.UserAgent,
+			CreatedAt:     timestamppb.New(v.CreatedAt),
+			LastSeenAt:    timestamppb.New(v.LastSeenAt),
+			Current:       v.ID == current,
+		})
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeSession(ctx context.Context, in *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if in.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "session id is empty")
+	}
+
+	if err := s.service.RevokeSession(ctx, in.GetId()); err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		if errors.Is(err, customerr.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, "session not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke session")
+	}
+	return &pb.RevokeSessionResponse{}, nil
+}
+
+// pbDeviceToDomain combines device described by the client with its address and user agent.
+func pbDeviceToDomain(ctx context.Context, in *pb.Device) models.Device {
+	device := models.Device{
+		Name:          truncate(in.GetName(), maxDeviceFieldLen),
+		ClientVersion: truncate(in.GetClientVersion(), maxDeviceFieldLen),
+		IP:            core.GetContextClientIP(ctx),
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range userAgentHeaders {
+			if values := md.Get(header); len(values) > 0 {
+				device.UserAgent = truncate(values[0], maxDeviceFieldLen)
+				break
+			}
+		}
+	}
+	return device
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Не разрезаем многобайтовый символ
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
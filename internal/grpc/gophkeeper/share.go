@@ -0,0 +1,105 @@
+package gophkeeper
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+func (s *serverAPI) ShareRecord(ctx context.Context, in *pb.ShareRecordRequest) (*pb.ShareRecordResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetRecipientEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "recipient email is empty")
+	}
+
+	var permission models.SharePermission
+	switch in.GetPermission() {
+	case pb.Share_READ_ONLY:
+		permission = models.ShareRead
+	case pb.Share_READ_WRITE:
+		permission = models.ShareWrite
+	default:
+		return nil, status.Error(codes.InvalidArgument, "permission is empty")
+	}
+
+	share, err := s.service.ShareRecord(ctx, in.GetId(), in.GetRecipientEmail(), permission)
+	if err != nil {
+		return nil, dataError(err, "failed to share record")
+	}
+	return &pb.ShareRecordResponse{Share: domainToPbShare(share)}, nil
+}
+
+func (s *serverAPI) RevokeShare(ctx context.Context, in *pb.RevokeShareRequest) (*pb.RevokeShareResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetRecipientEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "recipient email is empty")
+	}
+
+	if err := s.service.RevokeShare(ctx, in.GetId(), in.GetRecipientEmail()); err != nil {
+		return nil, dataError(err, "failed to revoke share")
+	}
+	return &pb.RevokeShareResponse{}, nil
+}
+
+func (s *serverAPI) ListSharedWithMe(ctx context.Context, in *pb.ListSharedWithMeRequest) (*pb.ListSharedWithMeResponse, error) {
+	records, err := s.service.ListSharedWithMe(ctx)
+	if err != nil {
+		return nil, dataError(err, "failed to list shared records")
+	}
+
+	resp := &pb.ListSharedWithMeResponse{Records: make([]*pb.SharedRecord, 0, len(records))}
+	for _, v := range records {
+		resp.Records = append(resp.Records, &pb.SharedRecord{
+			Share:  domainToPbShare(v.Share),
+			Record: domainToPbRecord(v.Record),
+		})
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) UpdateSharedRecord(ctx context.Context, in *pb.UpdateSharedRecordRequest) (*pb.UpdateSharedRecordResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetExpectedVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected version is empty")
+	}
+	secret, err := pbSecretToDomain(in.GetSecret())
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := pbAttributesToDomain(in.GetMetadata(), in.GetTags())
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.service.UpdateSharedRecord(ctx, in.GetId(), in.GetExpectedVersion(), secret, attrs)
+	if err != nil {
+		return nil, dataError(err, "failed to update shared record")
+	}
+	return &pb.UpdateSharedRecordResponse{Record: domainToPbRecord(record)}, nil
+}
+
+func domainToPbShare(share models.Share) *pb.Share {
+	permission := pb.Share_READ_ONLY
+	if share.Permission == models.ShareWrite {
+		permission = pb.Share_READ_WRITE
+	}
+	return &pb.Share{
+		RecordId:       share.DataID,
+		OwnerEmail:     share.OwnerEmail,
+		RecipientEmail: share.RecipientEmail,
+		Permission:     permission,
+		CreatedAt:      timestamppb.New(share.CreatedAt),
+	}
+}
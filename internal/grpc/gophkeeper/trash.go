@@ -0,0 +1,35 @@
+package gophkeeper
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+func (s *serverAPI) ListTrash(ctx context.Context, in *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	records, err := s.service.ListTrash(ctx)
+	if err != nil {
+		return nil, dataError(err, "failed to list trash")
+	}
+
+	resp := &pb.ListTrashResponse{Records: make([]*pb.Record, 0, len(records))}
+	for _, v := range records {
+		resp.Records = append(resp.Records, domainToPbRecord(v))
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) RestoreFromTrash(ctx context.Context, in *pb.RestoreFromTrashRequest) (*pb.RestoreFromTrashResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+
+	record, err := s.service.RestoreFromTrash(ctx, in.GetId())
+	if err != nil {
+		return nil, dataError(err, "failed to restore from trash")
+	}
+	return &pb.RestoreFromTrashResponse{Record: domainToPbRecord(record)}, nil
+}
@@ -0,0 +1,91 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+func (s *serverAPI) LoginTwoFactor(ctx context.Context, in *pb.LoginTwoFactorRequest) (*pb.LoginTwoFactorResponse, error) {
+	if in.GetChallengeToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "challenge token is empty")
+	}
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is empty")
+	}
+
+	tokens, err := s.service.LoginTwoFactor(ctx, in.GetChallengeToken(), in.GetCode(), pbDeviceToDomain(ctx, in.GetDevice()))
+	if err != nil {
+		if errors.Is(err, customerr.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, "challenge expired, login again")
+		}
+		if st, ok := limitStatus(err); ok {
+			return nil, st
+		}
+		return nil, twoFactorError(err, "failed to login")
+	}
+
+	return &pb.LoginTwoFactorResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *serverAPI) Enable2FA(ctx context.Context, _ *pb.Enable2FARequest) (*pb.Enable2FAResponse, error) {
+	enrollment, err := s.service.Enable2FA(ctx)
+	if err != nil {
+		return nil, twoFactorError(err, "failed to enable two-factor authentication")
+	}
+
+	return &pb.Enable2FAResponse{
+		Secret: enrollment.Secret,
+		Uri:    enrollment.URI,
+	}, nil
+}
+
+func (s *serverAPI) Confirm2FA(ctx context.Context, in *pb.Confirm2FARequest) (*pb.Confirm2FAResponse, error) {
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is empty")
+	}
+
+	if err := s.service.Confirm2FA(ctx, in.GetCode()); err != nil {
+		return nil, twoFactorError(err, "failed to confirm two-factor authentication")
+	}
+	return &pb.Confirm2FAResponse{}, nil
+}
+
+func (s *serverAPI) Disable2FA(ctx context.Context, in *pb.Disable2FARequest) (*pb.Disable2FAResponse, error) {
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is empty")
+	}
+
+	if err := s.service.Disable2FA(ctx, in.GetCode()); err != nil {
+		return nil, twoFactorError(err, "failed to disable two-factor authentication")
+	}
+	return &pb.Disable2FAResponse{}, nil
+}
+
+func twoFactorError(err error, msg string) error {
+	if st, ok := limitStatus(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, customerr.ErrFailedGetUserID):
+		return status.Error(codes.Unauthenticated, "not logged in")
+	case errors.Is(err, customerr.ErrSessionKeyNotFound):
+		return status.Error(codes.Unauthenticated, "session expired, login again")
+	case errors.Is(err, customerr.ErrInvalidTOTPCode):
+		return status.Error(codes.InvalidArgument, "invalid one-time code")
+	case errors.Is(err, customerr.ErrTwoFactorEnabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication already enabled")
+	case errors.Is(err, customerr.ErrTwoFactorDisabled):
+		return status.Error(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
@@ -0,0 +1,39 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+func (s *serverAPI) VerifyEmail(ctx context.Context, in *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if in.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is empty")
+	}
+
+	if err := s.service.VerifyEmail(ctx, in.GetCode()); err != nil {
+		if errors.Is(err, customerr.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid or used verification code")
+		}
+		return nil, status.Error(codes.Internal, "failed to verify email")
+	}
+	return &pb.VerifyEmailResponse{}, nil
+}
+
+func (s *serverAPI) SendVerificationEmail(ctx context.Context, _ *pb.SendVerificationEmailRequest) (*pb.SendVerificationEmailResponse, error) {
+	if err := s.service.SendVerificationEmail(ctx); err != nil {
+		if errors.Is(err, customerr.ErrFailedGetUserID) {
+			return nil, status.Error(codes.Unauthenticated, "not logged in")
+		}
+		if errors.Is(err, customerr.ErrEmailVerified) {
+			return nil, status.Error(codes.FailedPrecondition, "email already verified")
+		}
+		return nil, status.Error(codes.Internal, "failed to send verification email")
+	}
+	return &pb.SendVerificationEmailResponse{}, nil
+}
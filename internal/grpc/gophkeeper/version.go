@@ -0,0 +1,45 @@
+package gophkeeper
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+func (s *serverAPI) ListRecordVersions(ctx context.Context, in *pb.ListRecordVersionsRequest) (*pb.ListRecordVersionsResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+
+	versions, err := s.service.ListRecordVersions(ctx, in.GetId())
+	if err != nil {
+		return nil, dataError(err, "failed to list versions")
+	}
+
+	resp := &pb.ListRecordVersionsResponse{Versions: make([]*pb.Record, 0, len(versions))}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, domainToPbRecord(v))
+	}
+	return resp, nil
+}
+
+func (s *serverAPI) RestoreRecordVersion(ctx context.Context, in *pb.RestoreRecordVersionRequest) (*pb.RestoreRecordVersionResponse, error) {
+	if in.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id is empty")
+	}
+	if in.GetVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "version is empty")
+	}
+	if in.GetExpectedVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected version is empty")
+	}
+
+	record, err := s.service.RestoreRecordVersion(ctx, in.GetId(), in.GetVersion(), in.GetExpectedVersion())
+	if err != nil {
+		return nil, dataError(err, "failed to restore version")
+	}
+	return &pb.RestoreRecordVersionResponse{Record: domainToPbRecord(record)}, nil
+}
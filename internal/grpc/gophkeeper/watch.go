@@ -0,0 +1,122 @@
+package gophkeeper
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+)
+
+// watchHeartbeat is the longest pause between events of WatchChanges. Changes are also checked
+// on every heartbeat, so changes made through other server instances are delivered with this delay.
+// The session is validated again on every heartbeat, so a revoked session does not keep the stream open.
+const watchHeartbeat = 30 * time.Second
+
+func (s *serverAPI) WatchChanges(in *pb.WatchChangesRequest, stream pb.Gophkeeper_WatchChangesServer) error {
+	if in.GetSinceRevision() < 0 {
+		return status.Error(codes.InvalidArgument, "revision is negative")
+	}
+	ctx := stream.Context()
+	claims := &core.Claims{
+		UserID:    core.GetContextUserID(ctx),
+		Email:     core.GetContextEmail(ctx),
+		SessionID: core.GetContextSessionID(ctx),
+	}
+
+	// Подписываемся до первого чтения, чтобы не пропустить изменения между ними
+	notify, cancel, err := s.service.SubscribeChanges(ctx)
+	if err != nil {
+		return dataError(err, "failed to watch changes")
+	}
+	defer cancel()
+
+	cursor := in.GetSinceRevision()
+	if _, err = s.sendChanges(ctx, stream, &cursor); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-notify:
+			if _, err = s.sendChanges(ctx, stream, &cursor); err != nil {
+				return err
+			}
+			heartbeat.Reset(watchHeartbeat)
+		case <-heartbeat.C:
+			// Interceptor проверил сессию только при открытии потока
+			if err = s.service.ValidateSession(ctx, claims); err != nil {
+				return status.Error(codes.Unauthenticated, "session expired, login again")
+			}
+			sent, err := s.sendChanges(ctx, stream, &cursor)
+			if err != nil {
+				return err
+			}
+			if sent == 0 {
+				if err = stream.Send(&pb.ChangeEvent{Type: pb.ChangeEvent_HEARTBEAT, Revision: cursor}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// sendChanges sends all changes after cursor in revision order and moves cursor to the last sent one.
+func (s *serverAPI) sendChanges(ctx context.Context, stream pb.Gophkeeper_WatchChangesServer, cursor *int64) (int, error) {
+	var sent int
+	for {
+		changes, err := s.service.Sync(ctx, *cursor, defaultPageSize)
+		if err != nil {
+			return sent, dataError(err, "failed to watch changes")
+		}
+
+		for _, event := range changeEvents(changes) {
+			if err = stream.Send(event); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		*cursor = changes.Revision
+
+		if !changes.HasMore {
+			return sent, nil
+		}
+	}
+}
+
+// changeEvents converts a page of changes to events ordered by revision.
+func changeEvents(changes models.Changes) []*pb.ChangeEvent {
+	events := make([]*pb.ChangeEvent, 0, len(changes.Records)+len(changes.Deleted))
+	for _, v := range changes.Records {
+		typ := pb.ChangeEvent_UPDATED
+		if v.Version == 1 {
+			typ = pb.ChangeEvent_CREATED
+		}
+		events = append(events, &pb.ChangeEvent{
+			Type:     typ,
+			Revision: v.Revision,
+			Record:   domainToPbRecord(v),
+		})
+	}
+	for _, v := range changes.Deleted {
+		events = append(events, &pb.ChangeEvent{
+			Type:     pb.ChangeEvent_DELETED,
+			Revision: v.Revision,
+			Deleted:  domainToPbDeleted(v),
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].GetRevision() < events[j].GetRevision()
+	})
+	return events
+}
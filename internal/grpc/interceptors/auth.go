@@ -0,0 +1,176 @@
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+)
+
+// ISessionValidator checks that the session a valid token belongs to was not terminated.
+type ISessionValidator interface {
+	ValidateSession(ctx context.Context, claims *core.Claims) error
+}
+
+// ITokenParser verifies token signature and expiration.
+type ITokenParser interface {
+	ParseToken(token string) (*core.Claims, error)
+}
+
+// IAccessTokenValidator checks personal access tokens.
+type IAccessTokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string) (*core.Claims, error)
+}
+
+// Auth validates bearer tokens passed in request metadata and puts the caller identity into the context.
+// Methods listed in public are served without authentication.
+type Auth struct {
+	tokens   ITokenParser
+	sessions ISessionValidator
+	public   map[string]struct{}
+
+	accessTokens IAccessTokenValidator
+	// Методы, доступные по personal access token, значение true - метод изменяет данные
+	accessMethods map[string]bool
+}
+
+// NewAuth returns a new instance of the Auth interceptor
+func NewAuth(tokens ITokenParser, sessions ISessionValidator, public ...string) *Auth {
+	a := &Auth{
+		tokens:   tokens,
+		sessions: sessions,
+		public:   make(map[string]struct{}, len(public)),
+	}
+	for _, m := range public {
+		a.public[m] = struct{}{}
+	}
+	return a
+}
+
+// AllowAccessTokens accepts personal access tokens for the listed methods only.
+// Read-only tokens are rejected for writeMethods.
+func (a *Auth) AllowAccessTokens(validator IAccessTokenValidator, readMethods []string, writeMethods []string) *Auth {
+	a.accessTokens = validator
+	a.accessMethods = make(map[string]bool, len(readMethods)+len(writeMethods))
+	for _, m := range readMethods {
+		a.accessMethods[m] = false
+	}
+	for _, m := range writeMethods {
+		a.accessMethods[m] = true
+	}
+	return a
+}
+
+func (a *Auth) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if a.isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Auth) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if a.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (a *Auth) isPublic(method string) bool {
+	_, ok := a.public[method]
+	return ok
+}
+
+func (a *Auth) authenticate(ctx context.Context, method string) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(token, core.AccessTokenPrefix) {
+		return a.authenticateAccessToken(ctx, method, token)
+	}
+
+	claims, err := a.tokens.ParseToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if err = a.sessions.ValidateSession(ctx, claims); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "session expired, login again")
+	}
+	return core.WithClaims(ctx, claims), nil
+}
+
+func (a *Auth) authenticateAccessToken(ctx context.Context, method string, token string) (context.Context, error) {
+	writes, ok := a.accessMethods[method]
+	if a.accessTokens == nil || !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not available for access tokens")
+	}
+
+	claims, err := a.accessTokens.ValidateAccessToken(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	if writes && claims.Scope != nil && claims.Scope.ReadOnly {
+		return nil, status.Error(codes.PermissionDenied, "access token is read-only")
+	}
+	return core.WithClaims(ctx, claims), nil
+}
+
+// bearerToken extracts token from "authorization: Bearer <token>" metadata.
+// grpc-gateway forwards the HTTP Authorization header under the same key.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+
+	value := values[0]
+	if len(value) < len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+		return "", status.Error(codes.Unauthenticated, "invalid authorization scheme")
+	}
+
+	token := strings.TrimSpace(value[len(bearerPrefix):])
+	if token == "" {
+		return "", status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	return token, nil
+}
+
+// contextStream overrides the context of a wrapped server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,64 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+)
+
+const forwardedForHeader = "x-forwarded-for"
+
+// ClientIP puts the caller IP address into the context.
+// x-forwarded-for is trusted only from loopback peers, that is from the REST gateway running next to the server.
+type ClientIP struct{}
+
+// NewClientIP returns a new instance of the ClientIP interceptor
+func NewClientIP() *ClientIP {
+	return &ClientIP{}
+}
+
+func (c *ClientIP) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(core.WithClientIP(ctx, clientIP(ctx)), req)
+	}
+}
+
+func (c *ClientIP) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := core.WithClientIP(ss.Context(), clientIP(ss.Context()))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return host
+	}
+
+	// Gateway appends the address it has seen to the end of the list, earlier entries come from the client
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(forwardedForHeader); len(values) > 0 {
+			forwarded := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(forwarded[len(forwarded)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	return host
+}
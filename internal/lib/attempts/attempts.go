@@ -0,0 +1,147 @@
+// Package attempts counts failed attempts per key and blocks the key with exponential backoff.
+package attempts
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// forgetAfter is how long a key is remembered after its last failure or block.
+	forgetAfter = time.Hour
+	// sweepEvery limits how often forgotten keys are dropped, so a flood of failures
+	// with unique keys does not cost a full scan each.
+	sweepEvery = time.Minute
+	// busyDelay is returned when the key has no free attempts left and another attempt is in progress.
+	busyDelay = time.Second
+)
+
+// Limiter allows free attempts per key, every next failure blocks the key
+// for baseDelay doubled with each failure, up to maxDelay.
+// Attempts are reserved with Acquire before the credentials are checked and end with Fail or Release,
+// so parallel attempts can not all pass before the first failure is counted.
+// State is kept in memory, so it is per process and is reset on restart.
+type Limiter struct {
+	free      int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	failures     int
+	pending      int
+	blockedUntil time.Time
+	lastSeen     time.Time
+}
+
+// New returns a new instance of the Limiter
+func New(free int, baseDelay time.Duration, maxDelay time.Duration) *Limiter {
+	return &Limiter{
+		free:      free,
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		entries:   make(map[string]*entry),
+	}
+}
+
+// Acquire reserves an attempt of the key and returns zero, or returns time left until the key can be tried again.
+// Attempts in progress count as failures until they end, once free attempts are used up
+// only one attempt at a time is allowed. A reserved attempt must end with Fail or Release.
+func (l *Limiter) Acquire(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry{}
+		l.entries[key] = e
+	}
+	if left := e.blockedUntil.Sub(now); left > 0 {
+		return left
+	}
+	if e.pending > 0 && e.failures+e.pending >= l.free {
+		return busyDelay
+	}
+	e.pending++
+	e.lastSeen = now
+	return 0
+}
+
+// Release ends a reserved attempt which did not fail.
+func (l *Limiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return
+	}
+	if e.pending > 0 {
+		e.pending--
+	}
+	if e.pending == 0 && e.failures == 0 {
+		delete(l.entries, key)
+	}
+}
+
+// Fail ends a reserved attempt as failed and returns for how long the key is blocked now, zero if not blocked.
+func (l *Limiter) Fail(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry{}
+		l.entries[key] = e
+	}
+	if e.pending > 0 {
+		e.pending--
+	}
+	e.failures++
+	e.lastSeen = now
+
+	if e.failures <= l.free {
+		return 0
+	}
+
+	delay := l.maxDelay
+	// Сдвиг ограничен, чтобы не переполнить Duration
+	if shift := e.failures - l.free - 1; shift < 32 {
+		if d := l.baseDelay << shift; d > 0 && d < l.maxDelay {
+			delay = d
+		}
+	}
+	e.blockedUntil = now.Add(delay)
+	return delay
+}
+
+// Reset forgets failures of the key after a successful attempt, attempts in progress end with no effect.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+// sweep drops keys not seen for forgetAfter, at most once per sweepEvery. Caller must hold the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepEvery {
+		return
+	}
+	l.lastSweep = now
+
+	for key, e := range l.entries {
+		// Попытки в процессе держат ключ, даже если они длятся дольше forgetAfter
+		if e.pending == 0 && now.Sub(e.lastSeen) > forgetAfter && now.After(e.blockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
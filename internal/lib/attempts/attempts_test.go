@@ -0,0 +1,104 @@
+package attempts
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_ParallelAttemptsAreBounded(t *testing.T) {
+	l := New(3, time.Minute, time.Hour)
+
+	// Параллельно проходят только бесплатные попытки, остальные ждут их результата
+	for i := 0; i < 3; i++ {
+		require.Zero(t, l.Acquire("key"), "attempt %d", i)
+	}
+	assert.Equal(t, busyDelay, l.Acquire("key"))
+
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, l.Fail("key"))
+	}
+
+	require.Zero(t, l.Acquire("key"))
+	assert.Equal(t, busyDelay, l.Acquire("key"), "only one attempt at a time without free attempts")
+	assert.Equal(t, time.Minute, l.Fail("key"))
+
+	left := l.Acquire("key")
+	assert.Greater(t, left, time.Duration(0))
+	assert.LessOrEqual(t, left, time.Minute)
+}
+
+func TestLimiter_ConcurrentGuesses(t *testing.T) {
+	const free = 5
+	l := New(free, time.Minute, time.Hour)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	start := make(chan struct{})
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if l.Acquire("key") > 0 {
+				return
+			}
+			mu.Lock()
+			allowed++
+			mu.Unlock()
+			// Проверка пароля медленная, все горутины успевают попытаться до первого Fail
+			time.Sleep(10 * time.Millisecond)
+			l.Fail("key")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// Опоздавшая горутина может получить ещё одну попытку после всех Fail, её неудача блокирует ключ
+	assert.GreaterOrEqual(t, allowed, free)
+	assert.LessOrEqual(t, allowed, free+1)
+}
+
+func TestLimiter_ReleaseAndReset(t *testing.T) {
+	l := New(1, time.Minute, time.Hour)
+
+	require.Zero(t, l.Acquire("key"))
+	l.Release("key")
+	assert.Empty(t, l.entries, "successful attempts leave nothing behind")
+
+	require.Zero(t, l.Acquire("key"))
+	l.Fail("key")
+	require.Zero(t, l.Acquire("key"))
+	require.Greater(t, l.Fail("key"), time.Duration(0))
+	require.Greater(t, l.Acquire("key"), time.Duration(0))
+
+	l.Reset("key")
+	assert.Zero(t, l.Acquire("key"))
+	l.Release("key")
+	// Release после Reset не должен уводить счётчик в минус
+	l.Release("key")
+	assert.Empty(t, l.entries)
+}
+
+func TestLimiter_SweepIsRateLimited(t *testing.T) {
+	l := New(1, time.Minute, time.Hour)
+
+	now := time.Now()
+	old := now.Add(-2 * forgetAfter)
+	l.entries["stale"] = &entry{failures: 1, lastSeen: old, blockedUntil: old}
+	l.entries["pending"] = &entry{pending: 1, lastSeen: old}
+
+	l.lastSweep = now
+	l.sweep(now.Add(sweepEvery / 2))
+	assert.Contains(t, l.entries, "stale", "sweep runs at most once per sweepEvery")
+
+	l.sweep(now.Add(sweepEvery))
+	assert.NotContains(t, l.entries, "stale")
+	assert.Contains(t, l.entries, "pending", "keys with attempts in progress are kept")
+}
@@ -0,0 +1,57 @@
+// Package broker notifies subscribers in the same process about changes of user records.
+package broker
+
+import "sync"
+
+// Broker delivers change notifications to subscribers of the user. Notifications carry no data,
+// subscribers read changes from storage, so several notifications not yet received are merged into one.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan struct{}]struct{}
+}
+
+// New returns a new instance of the Broker
+func New() *Broker {
+	return &Broker{
+		subs: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// Publish notifies all subscribers of the user without blocking.
+func (b *Broker) Publish(userID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// Подписчик ещё не обработал предыдущее уведомление
+		}
+	}
+}
+
+// Subscribe returns channel receiving notifications about changes of the user records
+// and the function to cancel the subscription.
+func (b *Broker) Subscribe(userID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[chan struct{}]struct{})
+	}
+	b.subs[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs[userID], ch)
+			if len(b.subs[userID]) == 0 {
+				delete(b.subs, userID)
+			}
+		})
+	}
+}
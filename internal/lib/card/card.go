@@ -0,0 +1,97 @@
+package card
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidNumber = errors.New("invalid card number")
+	ErrInvalidExpiry = errors.New("invalid card expiry")
+	ErrInvalidCVV    = errors.New("invalid card cvv")
+)
+
+const (
+	minNumberLen = 12
+	maxNumberLen = 19
+)
+
+// NormalizeNumber strips spaces and dashes commonly used to group card number digits.
+func NormalizeNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// ValidateNumber checks that number consists of digits only, has a valid length and passes the Luhn check.
+func ValidateNumber(number string) error {
+	if len(number) < minNumberLen || len(number) > maxNumberLen {
+		return ErrInvalidNumber
+	}
+	if !Luhn(number) {
+		return ErrInvalidNumber
+	}
+	return nil
+}
+
+// Luhn reports whether number passes the Luhn checksum.
+func Luhn(number string) bool {
+	if number == "" {
+		return false
+	}
+
+	var sum int
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ParseExpiry parses card expiry in MM/YY or MM/YYYY form and returns the last moment the card is valid.
+func ParseExpiry(expiry string) (time.Time, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(expiry), "/")
+	if !ok || len(month) != 2 || (len(year) != 2 && len(year) != 4) {
+		return time.Time{}, ErrInvalidExpiry
+	}
+
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return time.Time{}, ErrInvalidExpiry
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 0 {
+		return time.Time{}, ErrInvalidExpiry
+	}
+	if len(year) == 2 {
+		y += 2000
+	}
+
+	// Карта действует до конца указанного месяца
+	return time.Date(y, time.Month(m)+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond), nil
+}
+
+// ValidateCVV checks that cvv is 3 or 4 digits long.
+func ValidateCVV(cvv string) error {
+	if len(cvv) != 3 && len(cvv) != 4 {
+		return ErrInvalidCVV
+	}
+	for _, c := range cvv {
+		if c < '0' || c > '9' {
+			return ErrInvalidCVV
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package core
+
+import "context"
+
+// WithClientIP returns a copy of ctx carrying the caller IP address.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxKeyClientIP, ip)
+}
+
+func GetContextClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxKeyClientIP).(string)
+	return ip
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,37 +12,165 @@ import (
 )
 
 const (
-	Secret = "1234"
-	userID = "uid"
+	claimUserID    = "uid"
+	claimEmail     = "email"
+	claimSessionID = "sid"
+	claimExp       = "exp"
+	claimType      = "typ"
+
+	// typeChallenge marks tokens proving the password was checked while the second factor is pending.
+	// Access tokens have no type claim.
+	typeChallenge = "2fa"
+	// typeEmailVerification marks codes sent to confirm the email of the account.
+	typeEmailVerification = "email"
 )
 
-func NewToken(ctx context.Context, user *models.User, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+type ctxKey int
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims[userID] = user.ID
-	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(duration).Unix()
+const (
+	ctxKeyUserID ctxKey = iota
+	ctxKeyEmail
+	ctxKeySessionID
+	ctxKeyClientIP
+	ctxKeyAccessScope
+)
+
+// AccessTokenPrefix starts personal access tokens to tell them from JWT.
+const AccessTokenPrefix = "gpk_"
 
-	setContextUserID(ctx, user.ID)
+// Claims holds the identity extracted from a verified token.
+// Scope is set only for personal access tokens.
+type Claims struct {
+	UserID    int64
+	Email     string
+	SessionID string
+	Scope     *models.AccessScope
+}
 
-	tokenString, err := token.SignedString([]byte(Secret))
+// NewToken returns token signed by the signing key of the set, its ID is put into the kid header.
+func (s *KeySet) NewToken(user *models.User, sessionID string, duration time.Duration) (string, error) {
+	token := jwt.New(s.signing.method())
+	token.Header["kid"] = s.signing.ID
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims[claimUserID] = user.ID
+	claims[claimEmail] = user.Email
+	claims[claimSessionID] = sessionID
+	claims[claimExp] = time.Now().Add(duration).Unix()
+
+	tokenString, err := token.SignedString(s.signing.signKey)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-func setContextUserID(ctx context.Context, userID int64) {
-	context.WithValue(ctx, userID, userID)
+// ParseToken verifies access token signature and expiration and returns its claims.
+func (s *KeySet) ParseToken(tokenString string) (*Claims, error) {
+	return s.parse(tokenString, "")
+}
+
+// NewChallengeToken returns token to finish login with the second factor, SessionID holds the challenge ID.
+func (s *KeySet) NewChallengeToken(userID int64, challengeID string, duration time.Duration) (string, error) {
+	token := jwt.New(s.signing.method())
+	token.Header["kid"] = s.signing.ID
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims[claimUserID] = userID
+	claims[claimSessionID] = challengeID
+	claims[claimType] = typeChallenge
+	claims[claimExp] = time.Now().Add(duration).Unix()
+
+	return token.SignedString(s.signing.signKey)
+}
+
+// ParseChallengeToken verifies token issued by NewChallengeToken.
+func (s *KeySet) ParseChallengeToken(tokenString string) (*Claims, error) {
+	return s.parse(tokenString, typeChallenge)
+}
+
+// NewEmailVerificationToken returns code confirming that user owns email.
+func (s *KeySet) NewEmailVerificationToken(userID int64, email string, duration time.Duration) (string, error) {
+	token := jwt.New(s.signing.method())
+	token.Header["kid"] = s.signing.ID
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims[claimUserID] = userID
+	claims[claimEmail] = email
+	claims[claimType] = typeEmailVerification
+	claims[claimExp] = time.Now().Add(duration).Unix()
+
+	return token.SignedString(s.signing.signKey)
+}
+
+// ParseEmailVerificationToken verifies code issued by NewEmailVerificationToken.
+func (s *KeySet) ParseEmailVerificationToken(tokenString string) (*Claims, error) {
+	return s.parse(tokenString, typeEmailVerification)
+}
+
+func (s *KeySet) parse(tokenString string, typ string) (*Claims, error) {
+	token, err := jwt.Parse(tokenString, s.keyfunc,
+		jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256, AlgHS256}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("unexpected claims type")
+	}
+
+	uid, ok := claims[claimUserID].(float64)
+	if !ok || uid <= 0 {
+		return nil, fmt.Errorf("invalid %s claim", claimUserID)
+	}
+	if t, _ := claims[claimType].(string); t != typ {
+		return nil, fmt.Errorf("invalid %s claim", claimType)
+	}
+	email, _ := claims[claimEmail].(string)
+	sessionID, _ := claims[claimSessionID].(string)
+
+	return &Claims{
+		UserID:    int64(uid),
+		Email:     email,
+		SessionID: sessionID,
+	}, nil
+}
+
+// WithClaims returns a copy of ctx carrying user ID, email and session ID from claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	ctx = context.WithValue(ctx, ctxKeyUserID, claims.UserID)
+	ctx = context.WithValue(ctx, ctxKeyEmail, claims.Email)
+	if claims.Scope != nil {
+		ctx = context.WithValue(ctx, ctxKeyAccessScope, *claims.Scope)
+	}
+	return context.WithValue(ctx, ctxKeySessionID, claims.SessionID)
 }
 
 func GetContextUserID(ctx context.Context) int64 {
 	var id int64
-	if value, ok := ctx.Value(userID).(int64); ok {
+	if value, ok := ctx.Value(ctxKeyUserID).(int64); ok {
 		id = value
 	} else {
 		return 0
 	}
 	return id
 }
+
+func GetContextEmail(ctx context.Context) string {
+	email, _ := ctx.Value(ctxKeyEmail).(string)
+	return email
+}
+
+func GetContextSessionID(ctx context.Context) string {
+	sessionID, _ := ctx.Value(ctxKeySessionID).(string)
+	return sessionID
+}
+
+// GetContextAccessScope returns scope of the personal access token of the request, false for login sessions.
+func GetContextAccessScope(ctx context.Context) (models.AccessScope, bool) {
+	scope, ok := ctx.Value(ctxKeyAccessScope).(models.AccessScope)
+	return scope, ok
+}
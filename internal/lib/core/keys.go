@@ -0,0 +1,196 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+	AlgHS256 = "HS256"
+
+	minHMACKeySize = 32
+)
+
+// Key is a token signing key. A key loaded from a public PEM verifies tokens only,
+// that is enough for a retired key kept until tokens signed by it expire.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// LoadKey reads key from a PEM file for EdDSA and RS256 or from a raw secret file for HS256.
+func LoadKey(id string, alg string, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{ID: id, Algorithm: alg}
+	switch alg {
+	case AlgEdDSA:
+		if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			key.signKey = priv
+			key.verifyKey = priv.(crypto.Signer).Public()
+			return key, nil
+		}
+		key.verifyKey, err = jwt.ParseEdPublicKeyFromPEM(data)
+	case AlgRS256:
+		if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.signKey = priv
+			key.verifyKey = &priv.PublicKey
+			return key, nil
+		}
+		key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case AlgHS256:
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minHMACKeySize {
+			return Key{}, fmt.Errorf("key %s: HMAC secret must be at least %d bytes", id, minHMACKeySize)
+		}
+		key.signKey = secret
+		key.verifyKey = secret
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported algorithm %q", id, alg)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, err)
+	}
+	return key, nil
+}
+
+// GenerateKey returns a new random EdDSA key.
+func GenerateKey(id string) (Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{ID: id, Algorithm: AlgEdDSA, signKey: priv, verifyKey: pub}, nil
+}
+
+func (k Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// KeySet signs tokens with one key and verifies them with any key of the set, selected by kid header.
+// To rotate keys add a new one, make it the signing key and keep the old one until token_ttl passes.
+type KeySet struct {
+	signing Key
+	keys    map[string]Key
+}
+
+// NewKeySet returns a set signing with the key signingID, which must have a private part.
+func NewKeySet(signingID string, keys ...Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]Key, len(keys))}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("key id is empty")
+		}
+		if _, ok := set.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", k.ID)
+		}
+		set.keys[k.ID] = k
+	}
+
+	signing, ok := set.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s not found", signingID)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private part", signingID)
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// keyfunc selects verification key by kid and rejects tokens signed with another algorithm than the key's one.
+func (s *KeySet) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a set of public keys served to other services to verify tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public keys of the set. HS256 secrets are never published.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, k := range s.keys {
+		jwk := JWK{KeyID: k.ID, Algorithm: k.Algorithm, Use: "sig"}
+		switch pub := k.verifyKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// PublicKey decodes the verification key of a JWK produced by KeySet.JWKS.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: invalid Ed25519 key", k.KeyID)
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %q", k.KeyID, k.KeyType)
+	}
+}
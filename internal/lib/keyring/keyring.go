@@ -0,0 +1,107 @@
+package keyring
+
+import (
+	"sync"
+	"time"
+)
+
+// Keyring keeps unwrapped user secret keys in memory for the lifetime of authenticated sessions.
+// Keys are never persisted, after restart they are unlocked again on token refresh.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys map[string]entry
+}
+
+type entry struct {
+	userID    int64
+	key       []byte
+	expiresAt time.Time
+}
+
+// New returns a new instance of the Keyring
+func New() *Keyring {
+	return &Keyring{
+		keys: make(map[string]entry),
+	}
+}
+
+// Put stores key of the user session for ttl.
+func (k *Keyring) Put(sessionID string, userID int64, key []byte, ttl time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	for sid, e := range k.keys {
+		if now.After(e.expiresAt) {
+			k.remove(sid)
+		}
+	}
+
+	k.keys[sessionID] = entry{
+		userID:    userID,
+		key:       append([]byte(nil), key...),
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// Get returns key of the session if it exists, is not expired and belongs to the user.
+func (k *Keyring) Get(sessionID string, userID int64) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	e, ok := k.keys[sessionID]
+	if !ok || e.userID != userID || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return append([]byte(nil), e.key...), true
+}
+
+// Take returns key of the user session and removes it, so the key can be taken only once.
+func (k *Keyring) Take(sessionID string, userID int64) ([]byte, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	e, ok := k.keys[sessionID]
+	if !ok || e.userID != userID {
+		return nil, false
+	}
+	key := append([]byte(nil), e.key...)
+	expired := time.Now().After(e.expiresAt)
+	k.remove(sessionID)
+	if expired {
+		return nil, false
+	}
+	return key, true
+}
+
+// Delete forgets key of the session.
+func (k *Keyring) Delete(sessionID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.remove(sessionID)
+}
+
+// DeleteUser forgets keys of all sessions of the user.
+func (k *Keyring) DeleteUser(userID int64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for sid, e := range k.keys {
+		if e.userID == userID {
+			k.remove(sid)
+		}
+	}
+}
+
+// remove wipes key bytes before dropping the entry. Caller must hold the lock.
+func (k *Keyring) remove(sessionID string) {
+	e, ok := k.keys[sessionID]
+	if !ok {
+		return
+	}
+	for i := range e.key {
+		e.key[i] = 0
+	}
+	delete(k.keys, sessionID)
+}
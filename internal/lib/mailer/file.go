@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// File appends messages as JSON lines to a file instead of sending them.
+// Integration tests read verification codes from it.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile returns a new instance of the File mailer
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (m *File) Send(_ context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Log writes messages to the application log instead of sending them, for local runs.
+type Log struct {
+	log *slog.Logger
+}
+
+// NewLog returns a new instance of the Log mailer
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (m *Log) Send(_ context.Context, msg Message) error {
+	m.log.Info("email",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package mailer sends plain text emails through SMTP or records them locally for development and tests.
+package mailer
+
+// Message is a plain text email.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
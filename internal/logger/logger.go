@@ -34,3 +34,11 @@ func MustSetup(env string) *slog.Logger {
 	}
 	return log.Logger
 }
+
+// Err wraps err into a structured log attribute.
+func Err(err error) slog.Attr {
+	return slog.Attr{
+		Key:   "error",
+		Value: slog.StringValue(err.Error()),
+	}
+}
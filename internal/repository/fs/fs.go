@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+)
+
+// FS keeps every blob in its own directory with a file per chunk.
+type FS struct {
+	dir string
+}
+
+// New returns a new instance of the filesystem blob store rooted at dir
+func New(dir string) (*FS, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FS{dir: dir}, nil
+}
+
+// PutChunk writes chunk to a temporary file and renames it, so readers never see partial chunks.
+func (s *FS) PutChunk(_ context.Context, blobID string, index int, data []byte) error {
+	dir, err := s.blobDir(blobID)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, ".chunk-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, strconv.Itoa(index)))
+}
+
+func (s *FS) GetChunk(_ context.Context, blobID string, index int) ([]byte, error) {
+	dir, err := s.blobDir(blobID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, strconv.Itoa(index)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, customerr.ErrChunkNotFound
+	}
+	return data, err
+}
+
+// DeleteBlob removes all chunks of the blob, missing blobs are ignored.
+func (s *FS) DeleteBlob(_ context.Context, blobID string) error {
+	dir, err := s.blobDir(blobID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// blobDir returns directory of the blob. IDs are generated by the service,
+// anything that could escape the root is rejected anyway.
+func (s *FS) blobDir(blobID string) (string, error) {
+	if blobID == "" || blobID != filepath.Base(blobID) || blobID == "." || blobID == ".." {
+		return "", fmt.Errorf("invalid blob id %q", blobID)
+	}
+	return filepath.Join(s.dir, blobID), nil
+}
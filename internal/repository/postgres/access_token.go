@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const accessTokenColumns = `id, user_id, name, secret_hash, wrapped_key, read_only, tag,
+    created_at, expires_at, last_used_at, revoked_at`
+
+func (r *Postgres) CreateAccessToken(ctx context.Context, token models.AccessToken) (models.AccessToken, error) {
+	const op = "storage.postgres.CreateAccessToken"
+
+	query := `
+        INSERT INTO access_tokens (id, user_id, name, secret_hash, wrapped_key, read_only, tag, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING ` + accessTokenColumns
+	created, err := scanAccessToken(r.db.QueryRowContext(ctx, query,
+		token.ID, token.UserID, token.Name, token.SecretHash, token.WrappedKey,
+		token.Scope.ReadOnly, token.Scope.Tag, token.ExpiresAt))
+	if err != nil {
+		return models.AccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return created, nil
+}
+
+func (r *Postgres) GetAccessToken(ctx context.Context, id string) (models.AccessToken, error) {
+	const op = "storage.postgres.GetAccessToken"
+
+	query := "SELECT " + accessTokenColumns + " FROM access_tokens WHERE id = $1"
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AccessToken{}, customerr.ErrAccessTokenNotFound
+		}
+		return models.AccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return token, nil
+}
+
+// ListAccessTokens returns not revoked tokens of the user including expired ones, newest first.
+func (r *Postgres) ListAccessTokens(ctx context.Context, userID int64) ([]models.AccessToken, error) {
+	const op = "storage.postgres.ListAccessTokens"
+
+	query := "SELECT " + accessTokenColumns + ` FROM access_tokens
+        WHERE user_id = $1 AND revoked_at IS NULL
+        ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tokens []models.AccessToken
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tokens, nil
+}
+
+func (r *Postgres) TouchAccessToken(ctx context.Context, id string) error {
+	const op = "storage.postgres.TouchAccessToken"
+
+	query := "UPDATE access_tokens SET last_used_at = NOW() WHERE id = $1"
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) RevokeAccessToken(ctx context.Context, id string, userID int64) error {
+	const op = "storage.postgres.RevokeAccessToken"
+
+	query := `
+        UPDATE access_tokens SET revoked_at = NOW(), wrapped_key = NULL
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `
+	res, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+func scanAccessToken(row scanner) (models.AccessToken, error) {
+	var (
+		token      models.AccessToken
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.SecretHash, &token.WrappedKey,
+		&token.Scope.ReadOnly, &token.Scope.Tag, &token.CreatedAt, &token.ExpiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return models.AccessToken{}, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+func (r *Postgres) AddAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	const op = "storage.postgres.AddAuditEvent"
+
+	userID := sql.NullInt64{Int64: event.UserID, Valid: event.UserID != 0}
+
+	query := "INSERT INTO audit_log (user_id, event, ip, details) VALUES ($1, $2, $3, $4)"
+	if _, err := r.db.ExecContext(ctx, query, userID, event.Event, event.IP, event.Details); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
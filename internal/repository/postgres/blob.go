@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+)
+
+// PutChunk stores chunk of the blob as a large object, replacing the previous one with the same index.
+func (r *Postgres) PutChunk(ctx context.Context, blobID string, index int, data []byte) error {
+	const op = "storage.postgres.PutChunk"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT lo_unlink(oid) FROM blob_chunks WHERE blob_id = $1 AND idx = $2"
+	if _, err = tx.ExecContext(ctx, query, blobID, index); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	query = `
+        INSERT INTO blob_chunks (blob_id, idx, oid) VALUES ($1, $2, lo_from_bytea(0, $3))
+        ON CONFLICT (blob_id, idx) DO UPDATE SET oid = EXCLUDED.oid`
+	if _, err = tx.ExecContext(ctx, query, blobID, index, data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetChunk(ctx context.Context, blobID string, index int) ([]byte, error) {
+	const op = "storage.postgres.GetChunk"
+
+	var data []byte
+	query := "SELECT lo_get(oid) FROM blob_chunks WHERE blob_id = $1 AND idx = $2"
+	if err := r.db.QueryRowContext(ctx, query, blobID, index).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, customerr.ErrChunkNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return data, nil
+}
+
+// DeleteBlob removes all chunks of the blob, missing blobs are ignored.
+func (r *Postgres) DeleteBlob(ctx context.Context, blobID string) error {
+	const op = "storage.postgres.DeleteBlob"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		"SELECT lo_unlink(oid) FROM blob_chunks WHERE blob_id = $1",
+		"DELETE FROM blob_chunks WHERE blob_id = $1",
+	}
+	for _, query := range queries {
+		if _, err = tx.ExecContext(ctx, query, blobID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+func (r *Postgres) SaveData(ctx context.Context, data models.PersonalData, userID int64) ([]int64, error) {
+	const op = "storage.postgres.SaveData"
+
+	log := r.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	query := "INSERT INTO PERSONAL_DATA(ID, KIND, PDATA, WRAPPED_KEY, USER_ID, REVISION) VALUES ($1, $2, $3, $4, $5, $6) RETURNING ID"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Error("failed begin tx", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := nextRevisions(ctx, tx, userID, len(data.PData))
+	if err != nil {
+		log.Error("failed to get revision", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	ids := make([]int64, 0, len(data.PData))
+	for i, v := range data.PData {
+		var id int64
+		if err = tx.QueryRowContext(ctx, query, v.ID, v.Kind, v.Payload, v.WrappedKey, userID, revision+int64(i)).Scan(&id); err != nil {
+			log.Error("failed executing query", logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		if id <= 0 {
+			log.Error("last inserted id <= 0")
+			return nil, customerr.ErrFailedInsertData
+		}
+		if err = insertAttributes(ctx, tx, id, v.Attributes); err != nil {
+			log.Error("failed to insert attributes", logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("failed to commit tx", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return ids, nil
+}
+
+// NewDataIDs reserves IDs for n new records, data keys of records are bound to their IDs before insert.
+func (r *Postgres) NewDataIDs(ctx context.Context, n int) ([]int64, error) {
+	const op = "storage.postgres.NewDataIDs"
+
+	var ids []int64
+	query := "SELECT nextval(pg_get_serial_sequence('personal_data', 'id')) FROM generate_series(1, $1)"
+	if err := r.db.SelectContext(ctx, &ids, query, n); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return ids, nil
+}
+
+func (r *Postgres) GetData(ctx context.Context, id int64, userID int64) (models.Data, error) {
+	const op = "storage.postgres.GetData"
+
+	query := "SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0) FROM PERSONAL_DATA WHERE ID = $1 AND USER_ID = $2 AND DELETED_AT IS NULL"
+
+	var data models.Data
+	row := r.db.QueryRowContext(ctx, query, id, userID)
+	if err := row.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt, &data.Revision, &data.Version, &data.BlobID, &data.FolderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, customerr.ErrDataNotFound
+		}
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	res := []models.Data{data}
+	if err := loadAttributes(ctx, r.db, res); err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return res[0], nil
+}
+
+func (r *Postgres) ListData(ctx context.Context, userID int64, opts models.ListOptions) ([]models.Data, error) {
+	const op = "storage.postgres.ListData"
+
+	query := `
+        SELECT pd.ID, pd.KIND, pd.PDATA, pd.WRAPPED_KEY, pd.CREATED_AT, pd.UPDATED_AT, pd.REVISION, pd.VERSION, pd.BLOB_ID, COALESCE(pd.FOLDER_ID, 0) FROM PERSONAL_DATA pd
+        WHERE pd.USER_ID = $1 AND pd.ID > $2 AND pd.DELETED_AT IS NULL`
+	args := []any{userID, opts.AfterID}
+
+	if opts.Tag != "" {
+		args = append(args, opts.Tag)
+		query += fmt.Sprintf(`
+        AND EXISTS (SELECT 1 FROM PERSONAL_DATA_TAGS t WHERE t.DATA_ID = pd.ID AND t.TAG = $%d)`, len(args))
+	}
+	if opts.MetadataKey != "" {
+		args = append(args, opts.MetadataKey)
+		query += fmt.Sprintf(`
+        AND EXISTS (SELECT 1 FROM PERSONAL_DATA_META m WHERE m.DATA_ID = pd.ID AND m.KEY = $%d)`, len(args))
+	}
+
+	args = append(args, opts.Limit)
+	query += fmt.Sprintf(`
+        ORDER BY pd.ID
+        LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.Data
+	for rows.Next() {
+		var data models.Data
+		if err = rows.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt, &data.Revision, &data.Version, &data.BlobID, &data.FolderID); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = loadAttributes(ctx, r.db, res); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return res, nil
+}
+
+// UpdateData replaces the record if it still has version data.Version and increments the version.
+func (r *Postgres) UpdateData(ctx context.Context, data models.Data, userID int64) (models.Data, error) {
+	const op = "storage.postgres.UpdateData"
+
+	query := `
+        UPDATE PERSONAL_DATA SET KIND = $1, PDATA = $2, WRAPPED_KEY = $3, UPDATED_AT = NOW(), REVISION = $6,
+            VERSION = VERSION + 1
+        WHERE ID = $4 AND USER_ID = $5 AND VERSION = $7 AND DELETED_AT IS NULL
+        RETURNING ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0)
+    `
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := nextRevisions(ctx, tx, userID, 1)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Текущая версия уходит в историю, только если её и обновляем
+	archived, err := archiveVersion(ctx, tx, data.ID, userID, data.Version)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if !archived {
+		return models.Data{}, missingOrConflict(ctx, tx, data.ID, userID)
+	}
+
+	var res models.Data
+	row := tx.QueryRowContext(ctx, query, data.Kind, data.Payload, data.WrappedKey, data.ID, userID, revision, data.Version)
+	if err = row.Scan(&res.ID, &res.Kind, &res.Payload, &res.WrappedKey, &res.CreatedAt, &res.UpdatedAt, &res.Revision, &res.Version, &res.BlobID, &res.FolderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, missingOrConflict(ctx, tx, data.ID, userID)
+		}
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = deleteAttributes(ctx, tx, res.ID); err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if err = insertAttributes(ctx, tx, res.ID, data.Attributes); err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Data{}, fmt.Errorf("%s:%w", op, err)
+	}
+	res.Attributes = data.Attributes
+	return res, nil
+}
+
+// DeleteData moves the record to the trash if it still has the expected version.
+// Trashed records are hidden from all other methods until restored or purged.
+func (r *Postgres) DeleteData(ctx context.Context, id int64, userID int64, version int64) error {
+	const op = "storage.postgres.DeleteData"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := nextRevisions(ctx, tx, userID, 1)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	query := `
+        UPDATE PERSONAL_DATA SET DELETED_AT = NOW(), REVISION = $4
+        WHERE ID = $1 AND USER_ID = $2 AND VERSION = $3 AND DELETED_AT IS NULL`
+	res, err := tx.ExecContext(ctx, query, id, userID, version, revision)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if affected == 0 {
+		return missingOrConflict(ctx, tx, id, userID)
+	}
+
+	// Для синхронизации запись в корзине уже удалена
+	query = `
+        INSERT INTO PERSONAL_DATA_TOMBSTONES(DATA_ID, USER_ID, REVISION) VALUES ($1, $2, $3)
+        ON CONFLICT (DATA_ID) DO UPDATE SET REVISION = EXCLUDED.REVISION, DELETED_AT = NOW()`
+	if _, err = tx.ExecContext(ctx, query, id, userID, revision); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// ListChanges returns up to limit records and up to limit tombstones of the user changed after revision since,
+// both ordered by revision.
+func (r *Postgres) ListChanges(ctx context.Context, userID int64, since int64, limit int) ([]models.Data, []models.Tombstone, error) {
+	const op = "storage.postgres.ListChanges"
+
+	query := `
+        SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0) FROM PERSONAL_DATA
+        WHERE USER_ID = $1 AND REVISION > $2 AND DELETED_AT IS NULL
+        ORDER BY REVISION
+        LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var data []models.Data
+	for rows.Next() {
+		var d models.Data
+		if err = rows.Scan(&d.ID, &d.Kind, &d.Payload, &d.WrappedKey, &d.CreatedAt, &d.UpdatedAt, &d.Revision, &d.Version, &d.BlobID, &d.FolderID); err != nil {
+			return nil, nil, fmt.Errorf("%s:%w", op, err)
+		}
+		data = append(data, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", op, err)
+	}
+	if err = loadAttributes(ctx, r.db, data); err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	query = `
+        SELECT DATA_ID, REVISION, DELETED_AT FROM PERSONAL_DATA_TOMBSTONES
+        WHERE USER_ID = $1 AND REVISION > $2
+        ORDER BY REVISION
+        LIMIT $3`
+
+	tombRows, err := r.db.QueryContext(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer tombRows.Close()
+
+	var tombstones []models.Tombstone
+	for tombRows.Next() {
+		var t models.Tombstone
+		if err = tombRows.Scan(&t.DataID, &t.Revision, &t.DeletedAt); err != nil {
+			return nil, nil, fmt.Errorf("%s:%w", op, err)
+		}
+		tombstones = append(tombstones, t)
+	}
+	if err = tombRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return data, tombstones, nil
+}
+
+// ListUnencryptedData returns legacy plaintext records of the user.
+func (r *Postgres) ListUnencryptedData(ctx context.Context, userID int64) ([]models.Data, error) {
+	const op = "storage.postgres.ListUnencryptedData"
+
+	query := `
+        SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0) FROM PERSONAL_DATA
+        WHERE USER_ID = $1 AND WRAPPED_KEY IS NULL
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.Data
+	for rows.Next() {
+		var data models.Data
+		if err = rows.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt, &data.Revision, &data.Version, &data.BlobID, &data.FolderID); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = loadAttributes(ctx, r.db, res); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return res, nil
+}
+
+// StoreEncryptedData replaces payload, data key and metadata of legacy plaintext records with
+// their encrypted versions. Modification time is kept, since the content itself does not change.
+func (r *Postgres) StoreEncryptedData(ctx context.Context, data []models.Data, userID int64) error {
+	const op = "storage.postgres.StoreEncryptedData"
+
+	query := `
+        UPDATE PERSONAL_DATA SET PDATA = $1, WRAPPED_KEY = $2
+        WHERE ID = $3 AND USER_ID = $4 AND WRAPPED_KEY IS NULL
+    `
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, v := range data {
+		res, err := tx.ExecContext(ctx, query, v.Payload, v.WrappedKey, v.ID, userID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		// Запись могла быть изменена или удалена параллельно, пропускаем её
+		if affected == 0 {
+			continue
+		}
+		if err = deleteAttributes(ctx, tx, v.ID); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if err = insertAttributes(ctx, tx, v.ID, v.Attributes); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// missingOrConflict tells why a conditional write of the record affected nothing.
+func missingOrConflict(ctx context.Context, tx *sqlx.Tx, id int64, userID int64) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM PERSONAL_DATA WHERE ID = $1 AND USER_ID = $2 AND DELETED_AT IS NULL)"
+	if err := tx.QueryRowContext(ctx, query, id, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return customerr.ErrDataNotFound
+	}
+	return customerr.ErrVersionConflict
+}
+
+// nextRevisions reserves n consecutive revisions of the user and returns the first of them.
+// The user row stays locked until tx ends, so revisions become visible to readers in increasing order.
+func nextRevisions(ctx context.Context, tx *sqlx.Tx, userID int64, n int) (int64, error) {
+	var last int64
+	query := "UPDATE users SET revision = revision + $1 WHERE id = $2 RETURNING revision"
+	if err := tx.QueryRowContext(ctx, query, n, userID).Scan(&last); err != nil {
+		return 0, err
+	}
+	return last - int64(n) + 1, nil
+}
+
+func insertAttributes(ctx context.Context, tx *sqlx.Tx, dataID int64, attrs models.Attributes) error {
+	for k, v := range attrs.Metadata {
+		_, err := tx.ExecContext(ctx, "INSERT INTO PERSONAL_DATA_META(DATA_ID, KEY, VALUE) VALUES ($1, $2, $3)", dataID, k, v)
+		if err != nil {
+			return err
+		}
+	}
+	for _, tag := range attrs.Tags {
+		_, err := tx.ExecContext(ctx, "INSERT INTO PERSONAL_DATA_TAGS(DATA_ID, TAG) VALUES ($1, $2) ON CONFLICT DO NOTHING", dataID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteAttributes(ctx context.Context, tx *sqlx.Tx, dataID int64) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM PERSONAL_DATA_META WHERE DATA_ID = $1", dataID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM PERSONAL_DATA_TAGS WHERE DATA_ID = $1", dataID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// loadAttributes fills metadata and tags of data in place with two queries for the whole slice.
+func loadAttributes(ctx context.Context, q sqlx.QueryerContext, data []models.Data) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(data))
+	byID := make(map[int64]*models.Data, len(data))
+	for i := range data {
+		ids = append(ids, data[i].ID)
+		byID[data[i].ID] = &data[i]
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT DATA_ID, KEY, VALUE FROM PERSONAL_DATA_META WHERE DATA_ID = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id         int64
+			key, value string
+		)
+		if err = rows.Scan(&id, &key, &value); err != nil {
+			return err
+		}
+		d := byID[id]
+		if d.Metadata == nil {
+			d.Metadata = make(map[string]string)
+		}
+		d.Metadata[key] = value
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	tagRows, err := q.QueryContext(ctx, "SELECT DATA_ID, TAG FROM PERSONAL_DATA_TAGS WHERE DATA_ID = ANY($1) ORDER BY TAG", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var (
+			id  int64
+			tag string
+		)
+		if err = tagRows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		byID[id].Tags = append(byID[id].Tags, tag)
+	}
+	return tagRows.Err()
+}
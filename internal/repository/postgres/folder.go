@@ -0,0 +1,354 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const folderColumns = "ID, COALESCE(PARENT_ID, 0), NAME, WRAPPED_KEY, CREATED_AT, UPDATED_AT"
+
+// CreateFolder stores a new folder of the user. Zero ParentID creates a top-level folder.
+func (r *Postgres) CreateFolder(ctx context.Context, folder models.FolderData, userID int64) (models.FolderData, error) {
+	const op = "storage.postgres.CreateFolder"
+
+	query := `
+        INSERT INTO FOLDERS(USER_ID, PARENT_ID, NAME, WRAPPED_KEY) VALUES ($1, NULLIF($2, 0), $3, $4)
+        RETURNING ` + folderColumns
+
+	res, err := scanFolder(r.db.QueryRowContext(ctx, query, userID, folder.ParentID, folder.Name, folder.WrappedKey))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.FolderData{}, customerr.ErrFolderNotFound
+		}
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+func (r *Postgres) GetFolder(ctx context.Context, id int64, userID int64) (models.FolderData, error) {
+	const op = "storage.postgres.GetFolder"
+
+	query := "SELECT " + folderColumns + " FROM FOLDERS WHERE ID = $1 AND USER_ID = $2"
+
+	res, err := scanFolder(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.FolderData{}, customerr.ErrFolderNotFound
+		}
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// ListFolders returns child folders of the parent, zero parentID lists top-level folders.
+func (r *Postgres) ListFolders(ctx context.Context, userID int64, parentID int64) ([]models.FolderData, error) {
+	const op = "storage.postgres.ListFolders"
+
+	query := `
+        SELECT ` + folderColumns + ` FROM FOLDERS
+        WHERE USER_ID = $1 AND PARENT_ID IS NOT DISTINCT FROM NULLIF($2, 0)
+        ORDER BY ID`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.FolderData
+	for rows.Next() {
+		folder, err := scanFolder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, folder)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// ListFolderData returns records in the folder, zero folderID lists records outside folders.
+func (r *Postgres) ListFolderData(ctx context.Context, userID int64, folderID int64) ([]models.Data, error) {
+	const op = "storage.postgres.ListFolderData"
+
+	query := `
+        SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0) FROM PERSONAL_DATA
+        WHERE USER_ID = $1 AND FOLDER_ID IS NOT DISTINCT FROM NULLIF($2, 0) AND DELETED_AT IS NULL
+        ORDER BY ID`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.Data
+	for rows.Next() {
+		var data models.Data
+		if err = rows.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt, &data.Revision, &data.Version, &data.BlobID, &data.FolderID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = loadAttributes(ctx, r.db, res); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// RenameFolder replaces the encrypted name of the folder. Records in the folder tree get new revisions,
+// so syncing clients learn about the change.
+func (r *Postgres) RenameFolder(ctx context.Context, id int64, userID int64, name []byte) (models.FolderData, error) {
+	const op = "storage.postgres.RenameFolder"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Пользователь блокируется раньше папки, в том же порядке, что и при перемещении
+	if err = lockUser(ctx, tx, userID); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+        UPDATE FOLDERS SET NAME = $3, UPDATED_AT = NOW()
+        WHERE ID = $1 AND USER_ID = $2
+        RETURNING ` + folderColumns
+
+	res, err := scanFolder(tx.QueryRowContext(ctx, query, id, userID, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.FolderData{}, customerr.ErrFolderNotFound
+		}
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = touchFolderData(ctx, tx, id, userID); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// MoveFolder moves the folder under another parent, zero parentID makes it top-level.
+// Records in the folder tree get new revisions. ErrFolderCycle is returned when the parent is the folder itself
+// or one of its descendants.
+func (r *Postgres) MoveFolder(ctx context.Context, id int64, userID int64, parentID int64) (models.FolderData, error) {
+	const op = "storage.postgres.MoveFolder"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Параллельные перемещения могли бы вместе замкнуть цикл, поэтому они идут по очереди
+	if err = lockUser(ctx, tx, userID); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if parentID != 0 {
+		var cycle bool
+		query := `
+            WITH RECURSIVE ancestors(ID, PARENT_ID) AS (
+                SELECT ID, PARENT_ID FROM FOLDERS WHERE ID = $1 AND USER_ID = $3
+                UNION ALL
+                SELECT f.ID, f.PARENT_ID FROM FOLDERS f JOIN ancestors a ON f.ID = a.PARENT_ID
+            )
+            SELECT EXISTS (SELECT 1 FROM ancestors WHERE ID = $2)`
+		if err = tx.QueryRowContext(ctx, query, parentID, id, userID).Scan(&cycle); err != nil {
+			return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if cycle {
+			return models.FolderData{}, customerr.ErrFolderCycle
+		}
+	}
+
+	query := `
+        UPDATE FOLDERS SET PARENT_ID = NULLIF($3, 0), UPDATED_AT = NOW()
+        WHERE ID = $1 AND USER_ID = $2
+        RETURNING ` + folderColumns
+
+	res, err := scanFolder(tx.QueryRowContext(ctx, query, id, userID, parentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isForeignKeyViolation(err) {
+			return models.FolderData{}, customerr.ErrFolderNotFound
+		}
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = touchFolderData(ctx, tx, id, userID); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.FolderData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// DeleteFolder removes an empty folder. Records of the folder in the trash are moved out of folders
+// with new revisions.
+func (r *Postgres) DeleteFolder(ctx context.Context, id int64, userID int64) error {
+	const op = "storage.postgres.DeleteFolder"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err = lockUser(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var notEmpty bool
+	query := `
+        SELECT EXISTS (SELECT 1 FROM FOLDERS WHERE PARENT_ID = $1 AND USER_ID = $2)
+            OR EXISTS (SELECT 1 FROM PERSONAL_DATA WHERE FOLDER_ID = $1 AND USER_ID = $2 AND DELETED_AT IS NULL)`
+	if err = tx.QueryRowContext(ctx, query, id, userID).Scan(&notEmpty); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if notEmpty {
+		return customerr.ErrFolderNotEmpty
+	}
+
+	if err = touchFolderData(ctx, tx, id, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = "UPDATE PERSONAL_DATA SET FOLDER_ID = NULL WHERE FOLDER_ID = $1 AND USER_ID = $2"
+	if _, err = tx.ExecContext(ctx, query, id, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM FOLDERS WHERE ID = $1 AND USER_ID = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrFolderNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// MoveData puts the record into the folder, zero folderID moves it out of folders.
+// The record gets a new revision, its version stays the same.
+func (r *Postgres) MoveData(ctx context.Context, id int64, userID int64, folderID int64) (models.Data, error) {
+	const op = "storage.postgres.MoveData"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := nextRevisions(ctx, tx, userID, 1)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+        UPDATE PERSONAL_DATA SET FOLDER_ID = NULLIF($3, 0), REVISION = $4
+        WHERE ID = $1 AND USER_ID = $2 AND DELETED_AT IS NULL
+        RETURNING ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0)`
+
+	var data models.Data
+	row := tx.QueryRowContext(ctx, query, id, userID, folderID, revision)
+	if err = row.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt, &data.Revision, &data.Version, &data.BlobID, &data.FolderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, customerr.ErrDataNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return models.Data{}, customerr.ErrFolderNotFound
+		}
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := []models.Data{data}
+	if err = loadAttributes(ctx, tx, res); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res[0], nil
+}
+
+// touchFolderData gives new revisions to all records, trashed included, in the folder and its descendants.
+// Folders are not in the change feed themselves, so clients see their changes through the records.
+func touchFolderData(ctx context.Context, tx *sqlx.Tx, folderID int64, userID int64) error {
+	var ids []int64
+	query := `
+        WITH RECURSIVE tree(ID) AS (
+            SELECT ID FROM FOLDERS WHERE ID = $1 AND USER_ID = $2
+            UNION ALL
+            SELECT f.ID FROM FOLDERS f JOIN tree t ON f.PARENT_ID = t.ID
+        )
+        SELECT ID FROM PERSONAL_DATA WHERE USER_ID = $2 AND FOLDER_ID IN (SELECT ID FROM tree)
+        ORDER BY ID`
+	if err := tx.SelectContext(ctx, &ids, query, folderID, userID); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	revision, err := nextRevisions(ctx, tx, userID, len(ids))
+	if err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE PERSONAL_DATA SET REVISION = $2 + n.ORD - 1
+        FROM UNNEST($1::BIGINT[]) WITH ORDINALITY AS n(ID, ORD)
+        WHERE PERSONAL_DATA.ID = n.ID`
+	_, err = tx.ExecContext(ctx, query, pq.Array(ids), revision)
+	return err
+}
+
+// lockUser serializes folder tree changes of the user.
+func lockUser(ctx context.Context, tx *sqlx.Tx, userID int64) error {
+	var id int64
+	return tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&id)
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgerrcode.ForeignKeyViolation
+}
+
+func scanFolder(row scanner) (models.FolderData, error) {
+	var folder models.FolderData
+	err := row.Scan(&folder.ID, &folder.ParentID, &folder.Name, &folder.WrappedKey, &folder.CreatedAt, &folder.UpdatedAt)
+	return folder, err
+}
@@ -14,8 +14,11 @@ import (
 
 	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
 	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
 )
 
+const userColumns = "id, email, password_hash, secret_key_hash, encrypted_key, recovery_key, totp_secret, totp_enabled, totp_last_step, email_verified_at, public_key, private_key"
+
 type Postgres struct {
 	log *slog.Logger
 	db  *sqlx.DB
@@ -33,11 +36,11 @@ func New(ctx context.Context, log *slog.Logger, connString string) (*Postgres, e
 	}
 
 	if err = goose.SetDialect("postgres"); err != nil {
-		log.Error("unable to set goose dialect", err.Error())
+		log.Error("unable to set goose dialect", logger.Err(err))
 		return nil, err
 	}
 	if err = goose.Up(db.DB, "migrations"); err != nil {
-		log.Error("failed to load migrations ", err.Error())
+		log.Error("failed to load migrations ", logger.Err(err))
 		return nil, err
 	}
 
@@ -71,19 +74,19 @@ func (r *Postgres) Login(ctx context.Context, email string) (models.User, error)
 	return user, nil
 }
 
-func (r *Postgres) Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte) (int64, error) {
+func (r *Postgres) Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte, recoveryKey []byte, keys models.KeyPair) (int64, error) {
 	const op = "storage.postgres.Register"
 	log := r.log.With(
 		slog.String("op", op),
 		slog.String("email", email))
 	var userID int64
 	query := `
-        INSERT INTO users (email, password_hash, secret_key_hash, encrypted_key)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO users (email, password_hash, secret_key_hash, encrypted_key, recovery_key, public_key, private_key)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id
     `
 
-	res := r.db.QueryRowContext(ctx, query, email, passHash, secretKeyHash, encryptedKey)
+	res := r.db.QueryRowContext(ctx, query, email, passHash, secretKeyHash, encryptedKey, recoveryKey, keys.PublicKey, keys.PrivateKey)
 	err := res.Scan(&userID)
 	if err != nil {
 		if err.(*pq.Error).Code == pgerrcode.UniqueViolation {
@@ -91,7 +94,7 @@ func (r *Postgres) Register(ctx context.Context, email string, passHash []byte,
 		}
 		return 0, fmt.Errorf("%s:%w", op, err)
 	}
-	log.Info("registered new user with ID", userID)
+	log.Info("registered new user", slog.Int64("userID", userID))
 	return userID, nil
 }
 
@@ -104,15 +107,14 @@ func (r *Postgres) GetUserByEmail(ctx context.Context, email string) (*models.Us
 	log.Info("getting user by email")
 
 	var user models.User
-	query := "SELECT id, email, password_hash, secret_key_hash, encrypted_key FROM users WHERE email = $1 LIMIT 1"
+	query := "SELECT " + userColumns + " FROM users WHERE email = $1 LIMIT 1"
 
-	row := r.db.QueryRowContext(ctx, query, email)
-	err := row.Scan(&user.ID, &user.Email, &user.PassHash, &user.SecretKeyHash, &user.EncryptedKey)
+	err := scanUser(r.db.QueryRowContext(ctx, query, email), &user)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, customerr.ErrUserNotFound
 		}
-		log.Error("failed to get user", err.Error())
+		log.Error("failed to get user", logger.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -120,60 +122,247 @@ func (r *Postgres) GetUserByEmail(ctx context.Context, email string) (*models.Us
 	return &user, nil
 }
 
-func (r *Postgres) SaveData(ctx context.Context, data models.PersonalData, userID int64) error {
-	var err error
-	const op = "storage.postgres.SaveData"
-
-	log := r.log.With(
-		slog.String("op", op),
-		slog.Int64("userID", userID))
+func (r *Postgres) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	const op = "storage.postgres.GetUserByID"
 
-	query := "INSERT INTO PERSONAL_DATA(PDATA, USER_ID) VALUES $1, $2"
+	var user models.User
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
 
-	tx, err := r.db.Begin()
+	err := scanUser(r.db.QueryRowContext(ctx, query, id), &user)
 	if err != nil {
-		log.Error("failed begin tx")
-		err = tx.Rollback()
-		if err != nil {
-			return fmt.Errorf("%s:%w", op, err)
-		}
-		return fmt.Errorf("%s:%w", op, err)
-	}
-
-	for _, v := range data.PData {
-		result, err := tx.ExecContext(ctx, query, v.Value, userID)
-		if err != nil {
-			log.Error("failed executing query")
-			err = tx.Rollback()
-			if err != nil {
-				return fmt.Errorf("%s:%w", op, err)
-			}
-			return fmt.Errorf("%s:%w", op, err)
-		}
-		id, err := result.LastInsertId()
-		if err != nil {
-			log.Error("failed to get last inert id")
-			err = tx.Rollback()
-			if err != nil {
-				return fmt.Errorf("%s:%w", op, err)
-			}
-			return fmt.Errorf("%s:%w", op, err)
-		}
-		if id <= 0 {
-			log.Error("last inserted id <= 0")
-			err = tx.Rollback()
-			if err != nil {
-				return fmt.Errorf("%s:%w", op, err)
-			}
-			return customerr.ErrFailedInsertData
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, customerr.ErrUserNotFound
 		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &user, nil
+}
+
+// UpdatePassword replaces password hash and secret key wrapped by the password and revokes all user sessions
+// in one transaction.
+func (r *Postgres) UpdatePassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte) error {
+	const op = "storage.postgres.UpdatePassword"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET password_hash = $1, encrypted_key = $2 WHERE id = $3"
+	res, err := tx.ExecContext(ctx, query, passHash, encryptedKey, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUserNotFound
+	}
+
+	if err = revokeUserSessions(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ResetPassword replaces password hash and both secret key copies wrapped by the password
+// and by the recovery code and revokes all user sessions in one transaction.
+func (r *Postgres) ResetPassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte, recoveryKey []byte) error {
+	const op = "storage.postgres.ResetPassword"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET password_hash = $1, encrypted_key = $2, recovery_key = $3 WHERE id = $4"
+	res, err := tx.ExecContext(ctx, query, passHash, encryptedKey, recoveryKey, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUserNotFound
+	}
+
+	if err = revokeUserSessions(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) UpdateEncryptedKey(ctx context.Context, userID int64, encryptedKey []byte) error {
+	const op = "storage.postgres.UpdateEncryptedKey"
+
+	query := "UPDATE users SET encrypted_key = $1 WHERE id = $2"
+
+	res, err := r.db.ExecContext(ctx, query, encryptedKey, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	err = tx.Commit()
+	affected, err := res.RowsAffected()
 	if err != nil {
-		log.Error("failed to commit tx")
-		if err != nil {
-			return fmt.Errorf("%s:%w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUserNotFound
+	}
+	return nil
+}
+
+// SetKeyPair stores the keypair of a user registered before sharing. An existing keypair is kept.
+func (r *Postgres) SetKeyPair(ctx context.Context, userID int64, keys models.KeyPair) error {
+	const op = "storage.postgres.SetKeyPair"
+
+	query := "UPDATE users SET public_key = $1, private_key = $2 WHERE id = $3 AND public_key IS NULL"
+
+	if _, err := r.db.ExecContext(ctx, query, keys.PublicKey, keys.PrivateKey, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UpdateTOTP stores encrypted TOTP secret, nil secret disables two-factor authentication.
+func (r *Postgres) UpdateTOTP(ctx context.Context, userID int64, secret []byte, enabled bool) error {
+	const op = "storage.postgres.UpdateTOTP"
+
+	query := "UPDATE users SET totp_secret = $1, totp_enabled = $2 WHERE id = $3"
+
+	res, err := r.db.ExecContext(ctx, query, secret, enabled, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUserNotFound
+	}
+	return nil
+}
+
+// VerifyEmail marks email of the user as confirmed. Returns ErrInvalidToken if the user has another email
+// or it is already confirmed, so every verification code works only once.
+func (r *Postgres) VerifyEmail(ctx context.Context, userID int64, email string) error {
+	const op = "storage.postgres.VerifyEmail"
+
+	query := "UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email = $2 AND email_verified_at IS NULL"
+
+	res, err := r.db.ExecContext(ctx, query, userID, email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrInvalidToken
+	}
+	return nil
+}
+
+// UseTOTPStep remembers the step of an accepted code. Returns ErrInvalidTOTPCode if the same
+// or a later step was already accepted, so every code works only once.
+func (r *Postgres) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.postgres.UseTOTPStep"
+
+	query := "UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1"
+
+	res, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func scanUser(row *sql.Row, user *models.User) error {
+	return row.Scan(&user.ID, &user.Email, &user.PassHash, &user.SecretKeyHash, &user.EncryptedKey,
+		&user.RecoveryKey, &user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep,
+		&user.EmailVerifiedAt, &user.Keys.PublicKey, &user.Keys.PrivateKey)
+}
+
+// DeleteUser removes the user with all records, folders, shares, uploads, sessions and access tokens in one transaction.
+// Audit history of the user is kept without the link to the account, tombstone is written in its place.
+func (r *Postgres) DeleteUser(ctx context.Context, userID int64, tombstone models.AuditEvent) error {
+	const op = "storage.postgres.DeleteUser"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Чанки файлов хранятся вне базы, их удалит фоновая задача по очереди PURGED_BLOBS
+	query := `
+        INSERT INTO PURGED_BLOBS (BLOB_ID)
+        SELECT BLOB_ID FROM PERSONAL_DATA WHERE USER_ID = $1 AND BLOB_ID <> ''
+        UNION
+        SELECT id FROM uploads WHERE user_id = $1
+        ON CONFLICT (BLOB_ID) DO NOTHING`
+	if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Метаданные и теги записей удаляются каскадно
+	queries := []string{
+		"DELETE FROM RECORD_SHARES WHERE RECIPIENT_ID = $1 OR OWNER_ID = $1",
+		"DELETE FROM PERSONAL_DATA WHERE USER_ID = $1",
+		"DELETE FROM FOLDERS WHERE USER_ID = $1",
+		"DELETE FROM PERSONAL_DATA_TOMBSTONES WHERE USER_ID = $1",
+		"DELETE FROM sessions WHERE user_id = $1",
+		"DELETE FROM access_tokens WHERE user_id = $1",
+		"DELETE FROM uploads WHERE user_id = $1",
+		"UPDATE audit_log SET user_id = NULL, details = '' WHERE user_id = $1",
+	}
+	for _, query := range queries {
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
 	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUserNotFound
+	}
+
+	query = "INSERT INTO audit_log (event, ip, details) VALUES ($1, $2, $3)"
+	if _, err = tx.ExecContext(ctx, query, tombstone.Event, tombstone.IP, tombstone.Details); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const sessionColumns = `id, user_id, refresh_hash, previous_hash, wrapped_key,
+    device_name, client_version, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *Postgres) CreateSession(ctx context.Context, session models.Session) error {
+	const op = "storage.postgres.CreateSession"
+
+	query := `
+        INSERT INTO sessions (id, user_id, refresh_hash, wrapped_key, expires_at,
+                              device_name, client_version, ip, user_agent)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.RefreshHash, session.WrappedKey, session.ExpiresAt,
+		session.Device.Name, session.Device.ClientVersion, session.Device.IP, session.Device.UserAgent)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetSession(ctx context.Context, id string) (models.Session, error) {
+	const op = "storage.postgres.GetSession"
+
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE id = $1"
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Session{}, customerr.ErrSessionNotFound
+		}
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return session, nil
+}
+
+// GetSessionByRefreshHash finds session by its current refresh token or by the one replaced last.
+func (r *Postgres) GetSessionByRefreshHash(ctx context.Context, refreshHash []byte) (models.Session, error) {
+	const op = "storage.postgres.GetSessionByRefreshHash"
+
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE refresh_hash = $1 OR previous_hash = $1 LIMIT 1"
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, refreshHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Session{}, customerr.ErrSessionNotFound
+		}
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return session, nil
+}
+
+// RotateSession replaces refresh token of an active session, if it is still oldHash.
+// Returns ErrSessionNotFound when the token was already rotated by a concurrent request.
+func (r *Postgres) RotateSession(ctx context.Context, id string, oldHash []byte, newHash []byte, wrappedKey []byte, expiresAt time.Time) error {
+	const op = "storage.postgres.RotateSession"
+
+	query := `
+        UPDATE sessions
+        SET previous_hash = refresh_hash, refresh_hash = $3, wrapped_key = $4, expires_at = $5, last_seen_at = NOW()
+        WHERE id = $1 AND refresh_hash = $2 AND revoked_at IS NULL AND expires_at > NOW()
+    `
+	res, err := r.db.ExecContext(ctx, query, id, oldHash, newHash, wrappedKey, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrSessionNotFound
+	}
+	return nil
+}
+
+// ListSessions returns active sessions of the user, recently used first.
+func (r *Postgres) ListSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	const op = "storage.postgres.ListSessions"
+
+	query := "SELECT " + sessionColumns + ` FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        ORDER BY last_seen_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// TouchSession updates last use time and, if known, the client IP of the session.
+func (r *Postgres) TouchSession(ctx context.Context, id string, ip string) error {
+	const op = "storage.postgres.TouchSession"
+
+	query := `
+        UPDATE sessions SET last_seen_at = NOW(), ip = COALESCE(NULLIF($2, ''), ip)
+        WHERE id = $1
+    `
+	if _, err := r.db.ExecContext(ctx, query, id, ip); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) RevokeSession(ctx context.Context, id string, userID int64) error {
+	const op = "storage.postgres.RevokeSession"
+
+	query := `
+        UPDATE sessions SET revoked_at = NOW(), wrapped_key = NULL
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `
+	res, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrSessionNotFound
+	}
+	return nil
+}
+
+// revokeUserSessions revokes all sessions of the user within tx, so they end together with the credentials change.
+func revokeUserSessions(ctx context.Context, tx *sqlx.Tx, userID int64) error {
+	query := `
+        UPDATE sessions SET revoked_at = NOW(), wrapped_key = NULL
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `
+	_, err := tx.ExecContext(ctx, query, userID)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row scanner) (models.Session, error) {
+	var (
+		session   models.Session
+		revokedAt sql.NullTime
+	)
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshHash, &session.PreviousHash, &session.WrappedKey,
+		&session.Device.Name, &session.Device.ClientVersion, &session.Device.IP, &session.Device.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		return models.Session{}, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
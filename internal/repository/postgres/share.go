@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const sharedDataColumns = `
+    s.DATA_ID, s.OWNER_ID, o.email, s.RECIPIENT_ID, s.WRAPPED_KEY, s.PERMISSION, s.CREATED_AT,
+    pd.ID, pd.KIND, pd.PDATA, pd.WRAPPED_KEY, pd.CREATED_AT, pd.UPDATED_AT, pd.REVISION, pd.VERSION, pd.BLOB_ID`
+
+// SaveShare gives the recipient access to the record or replaces the key and permission of an existing share.
+func (r *Postgres) SaveShare(ctx context.Context, share models.Share) (models.Share, error) {
+	const op = "storage.postgres.SaveShare"
+
+	query := `
+        INSERT INTO RECORD_SHARES(DATA_ID, RECIPIENT_ID, OWNER_ID, WRAPPED_KEY, PERMISSION)
+        SELECT ID, $2, USER_ID, $4, $5 FROM PERSONAL_DATA WHERE ID = $1 AND USER_ID = $3 AND DELETED_AT IS NULL
+        ON CONFLICT (DATA_ID, RECIPIENT_ID) DO UPDATE SET WRAPPED_KEY = EXCLUDED.WRAPPED_KEY, PERMISSION = EXCLUDED.PERMISSION
+        RETURNING CREATED_AT`
+
+	row := r.db.QueryRowContext(ctx, query, share.DataID, share.RecipientID, share.OwnerID, share.WrappedKey, share.Permission)
+	if err := row.Scan(&share.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Share{}, customerr.ErrDataNotFound
+		}
+		return models.Share{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return share, nil
+}
+
+// DeleteShare revokes access of the recipient to the record of the owner.
+func (r *Postgres) DeleteShare(ctx context.Context, dataID int64, ownerID int64, recipientEmail string) error {
+	const op = "storage.postgres.DeleteShare"
+
+	query := `
+        DELETE FROM RECORD_SHARES s USING users u
+        WHERE s.RECIPIENT_ID = u.id AND u.email = $3 AND s.DATA_ID = $1 AND s.OWNER_ID = $2`
+
+	res, err := r.db.ExecContext(ctx, query, dataID, ownerID, recipientEmail)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrShareNotFound
+	}
+	return nil
+}
+
+// ListSharedData returns records shared with the recipient, records in the trash are skipped.
+func (r *Postgres) ListSharedData(ctx context.Context, recipientID int64) ([]models.SharedData, error) {
+	const op = "storage.postgres.ListSharedData"
+
+	query := `
+        SELECT ` + sharedDataColumns + ` FROM RECORD_SHARES s
+        JOIN PERSONAL_DATA pd ON pd.ID = s.DATA_ID
+        JOIN users o ON o.id = s.OWNER_ID
+        WHERE s.RECIPIENT_ID = $1 AND pd.DELETED_AT IS NULL
+        ORDER BY s.CREATED_AT, s.DATA_ID`
+
+	rows, err := r.db.QueryContext(ctx, query, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.SharedData
+	for rows.Next() {
+		shared, err := scanSharedData(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, shared)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data := make([]models.Data, len(res))
+	for i := range res {
+		data[i] = res[i].Data
+	}
+	if err = loadAttributes(ctx, r.db, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for i := range res {
+		res[i].Data = data[i]
+	}
+	return res, nil
+}
+
+// GetSharedData returns a record shared with the recipient.
+func (r *Postgres) GetSharedData(ctx context.Context, dataID int64, recipientID int64) (models.SharedData, error) {
+	const op = "storage.postgres.GetSharedData"
+
+	query := `
+        SELECT ` + sharedDataColumns + ` FROM RECORD_SHARES s
+        JOIN PERSONAL_DATA pd ON pd.ID = s.DATA_ID
+        JOIN users o ON o.id = s.OWNER_ID
+        WHERE s.DATA_ID = $1 AND s.RECIPIENT_ID = $2 AND pd.DELETED_AT IS NULL`
+
+	shared, err := scanSharedData(r.db.QueryRowContext(ctx, query, dataID, recipientID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SharedData{}, customerr.ErrDataNotFound
+		}
+		return models.SharedData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data := []models.Data{shared.Data}
+	if err = loadAttributes(ctx, r.db, data); err != nil {
+		return models.SharedData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	shared.Data = data[0]
+	return shared, nil
+}
+
+func scanSharedData(row scanner) (models.SharedData, error) {
+	var shared models.SharedData
+	err := row.Scan(&shared.DataID, &shared.OwnerID, &shared.OwnerEmail, &shared.RecipientID, &shared.WrappedKey,
+		&shared.Permission, &shared.CreatedAt,
+		&shared.Data.ID, &shared.Data.Kind, &shared.Data.Payload, &shared.Data.WrappedKey, &shared.Data.CreatedAt,
+		&shared.Data.UpdatedAt, &shared.Data.Revision, &shared.Data.Version, &shared.Data.BlobID)
+	return shared, err
+}
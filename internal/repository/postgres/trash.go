@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+// ListTrash returns deleted records of the user, recently deleted first.
+func (r *Postgres) ListTrash(ctx context.Context, userID int64) ([]models.Data, error) {
+	const op = "storage.postgres.ListTrash"
+
+	query := `
+        SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0), DELETED_AT FROM PERSONAL_DATA
+        WHERE USER_ID = $1 AND DELETED_AT IS NOT NULL
+        ORDER BY DELETED_AT DESC, ID`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var res []models.Data
+	for rows.Next() {
+		data, err := scanTrashed(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = loadAttributes(ctx, r.db, res); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return res, nil
+}
+
+// GetTrashedData returns a deleted record of the user with its attributes.
+func (r *Postgres) GetTrashedData(ctx context.Context, id int64, userID int64) (models.Data, error) {
+	const op = "storage.postgres.GetTrashedData"
+
+	query := `
+        SELECT ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0), DELETED_AT FROM PERSONAL_DATA
+        WHERE ID = $1 AND USER_ID = $2 AND DELETED_AT IS NOT NULL`
+
+	data, err := scanTrashed(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, customerr.ErrDataNotFound
+		}
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := []models.Data{data}
+	if err = loadAttributes(ctx, r.db, res); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res[0], nil
+}
+
+// RestoreData moves the record out of the trash with a new revision and drops its tombstone.
+func (r *Postgres) RestoreData(ctx context.Context, id int64, userID int64) (models.Data, error) {
+	const op = "storage.postgres.RestoreData"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := nextRevisions(ctx, tx, userID, 1)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+        UPDATE PERSONAL_DATA SET DELETED_AT = NULL, REVISION = $3
+        WHERE ID = $1 AND USER_ID = $2 AND DELETED_AT IS NOT NULL
+        RETURNING ID, KIND, PDATA, WRAPPED_KEY, CREATED_AT, UPDATED_AT, REVISION, VERSION, BLOB_ID, COALESCE(FOLDER_ID, 0), DELETED_AT`
+
+	data, err := scanTrashed(tx.QueryRowContext(ctx, query, id, userID, revision))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, customerr.ErrDataNotFound
+		}
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = "DELETE FROM PERSONAL_DATA_TOMBSTONES WHERE DATA_ID = $1"
+	if _, err = tx.ExecContext(ctx, query, id); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := []models.Data{data}
+	if err = loadAttributes(ctx, tx, res); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return res[0], nil
+}
+
+// PurgeTrash removes records deleted before the given time for good and returns the number of removed records.
+// Their blob IDs are queued in PURGED_BLOBS in the same transaction, so the content is not lost track of.
+// Tombstones are kept, so clients still learn about the deletion.
+func (r *Postgres) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.PurgeTrash"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM PERSONAL_DATA WHERE DELETED_AT IS NOT NULL AND DELETED_AT < $1 RETURNING BLOB_ID"
+
+	var blobIDs []string
+	if err = tx.SelectContext(ctx, &blobIDs, query, before); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `
+        INSERT INTO PURGED_BLOBS (BLOB_ID)
+        SELECT ID FROM UNNEST($1::TEXT[]) AS ID WHERE ID <> ''
+        ON CONFLICT (BLOB_ID) DO NOTHING`
+	if _, err = tx.ExecContext(ctx, query, pq.Array(blobIDs)); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int64(len(blobIDs)), nil
+}
+
+// ListPurgedBlobs returns blob IDs of purged records which are not removed from the blob store yet.
+func (r *Postgres) ListPurgedBlobs(ctx context.Context) ([]string, error) {
+	const op = "storage.postgres.ListPurgedBlobs"
+
+	var blobIDs []string
+	if err := r.db.SelectContext(ctx, &blobIDs, "SELECT BLOB_ID FROM PURGED_BLOBS ORDER BY PURGED_AT"); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return blobIDs, nil
+}
+
+// DeletePurgedBlob forgets the blob once it is removed from the blob store.
+func (r *Postgres) DeletePurgedBlob(ctx context.Context, blobID string) error {
+	const op = "storage.postgres.DeletePurgedBlob"
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM PURGED_BLOBS WHERE BLOB_ID = $1", blobID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func scanTrashed(row scanner) (models.Data, error) {
+	var (
+		data      models.Data
+		deletedAt sql.NullTime
+	)
+	err := row.Scan(&data.ID, &data.Kind, &data.Payload, &data.WrappedKey, &data.CreatedAt, &data.UpdatedAt,
+		&data.Revision, &data.Version, &data.BlobID, &data.FolderID, &deletedAt)
+	if deletedAt.Valid {
+		data.DeletedAt = &deletedAt.Time
+	}
+	return data, err
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const uploadColumns = "id, user_id, size, wrapped_key, meta, received, hash_state, chunk_size, created_at, expires_at"
+
+func (r *Postgres) CreateUpload(ctx context.Context, upload models.Upload) error {
+	const op = "storage.postgres.CreateUpload"
+
+	query := `
+        INSERT INTO uploads (id, user_id, size, wrapped_key, meta, chunk_size, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query, upload.ID, upload.UserID, upload.Size, upload.WrappedKey,
+		upload.Meta, upload.ChunkSize, upload.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetUpload(ctx context.Context, id string, userID int64) (models.Upload, error) {
+	const op = "storage.postgres.GetUpload"
+
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE id = $1 AND user_id = $2 AND expires_at > NOW()"
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Upload{}, customerr.ErrUploadNotFound
+		}
+		return models.Upload{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return upload, nil
+}
+
+// ListExpiredUploads returns abandoned uploads of all users.
+func (r *Postgres) ListExpiredUploads(ctx context.Context) ([]models.Upload, error) {
+	const op = "storage.postgres.ListExpiredUploads"
+
+	query := "SELECT " + uploadColumns + " FROM uploads WHERE expires_at <= NOW() ORDER BY expires_at"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var uploads []models.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		uploads = append(uploads, upload)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return uploads, nil
+}
+
+// AdvanceUpload stores progress of the upload if it still has received bytes.
+// Returns ErrUploadOffset when another request stored a chunk in between.
+func (r *Postgres) AdvanceUpload(ctx context.Context, id string, received int64, newReceived int64, hashState []byte) error {
+	const op = "storage.postgres.AdvanceUpload"
+
+	query := "UPDATE uploads SET received = $1, hash_state = $2 WHERE id = $3 AND received = $4"
+	res, err := r.db.ExecContext(ctx, query, newReceived, hashState, id, received)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return customerr.ErrUploadOffset
+	}
+	return nil
+}
+
+// CompleteUpload creates the file record and removes the upload in one transaction.
+func (r *Postgres) CompleteUpload(ctx context.Context, id string, data models.Data, userID int64) (int64, error) {
+	const op = "storage.postgres.CompleteUpload"
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM uploads WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return 0, customerr.ErrUploadNotFound
+	}
+
+	revision, err := nextRevisions(ctx, tx, userID, 1)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var dataID int64
+	query := "INSERT INTO PERSONAL_DATA(ID, KIND, PDATA, WRAPPED_KEY, USER_ID, REVISION, BLOB_ID) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ID"
+	row := tx.QueryRowContext(ctx, query, data.ID, data.Kind, data.Payload, data.WrappedKey, userID, revision, data.BlobID)
+	if err = row.Scan(&dataID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = insertAttributes(ctx, tx, dataID, data.Attributes); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return dataID, nil
+}
+
+func (r *Postgres) DeleteUpload(ctx context.Context, id string) error {
+	const op = "storage.postgres.DeleteUpload"
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM uploads WHERE id = $1", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func scanUpload(row scanner) (models.Upload, error) {
+	var upload models.Upload
+	err := row.Scan(&upload.ID, &upload.UserID, &upload.Size, &upload.WrappedKey, &upload.Meta, &upload.Received,
+		&upload.HashState, &upload.ChunkSize, &upload.CreatedAt, &upload.ExpiresAt)
+	return upload, err
+}
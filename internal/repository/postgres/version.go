@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+const versionColumns = "v.DATA_ID, v.VERSION, v.KIND, v.PDATA, v.WRAPPED_KEY, v.METADATA, v.TAGS, v.UPDATED_AT"
+
+// ListDataVersions returns previous versions of the user record, newest first.
+func (r *Postgres) ListDataVersions(ctx context.Context, id int64, userID int64) ([]models.Data, error) {
+	const op = "storage.postgres.ListDataVersions"
+
+	query := `
+        SELECT ` + versionColumns + ` FROM PERSONAL_DATA_VERSIONS v
+        JOIN PERSONAL_DATA pd ON pd.ID = v.DATA_ID
+        WHERE v.DATA_ID = $1 AND pd.USER_ID = $2 AND pd.DELETED_AT IS NULL
+        ORDER BY v.VERSION DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var versions []models.Data
+	for rows.Next() {
+		data, err := scanVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		versions = append(versions, data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return versions, nil
+}
+
+func (r *Postgres) GetDataVersion(ctx context.Context, id int64, userID int64, version int64) (models.Data, error) {
+	const op = "storage.postgres.GetDataVersion"
+
+	query := `
+        SELECT ` + versionColumns + ` FROM PERSONAL_DATA_VERSIONS v
+        JOIN PERSONAL_DATA pd ON pd.ID = v.DATA_ID
+        WHERE v.DATA_ID = $1 AND pd.USER_ID = $2 AND v.VERSION = $3 AND pd.DELETED_AT IS NULL`
+
+	data, err := scanVersion(r.db.QueryRowContext(ctx, query, id, userID, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Data{}, customerr.ErrDataVersionNotFound
+		}
+		return models.Data{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return data, nil
+}
+
+// PruneDataVersions removes versions archived before the given time and all but keep newest versions
+// of every record. Zero keep or zero before disables the corresponding limit.
+func (r *Postgres) PruneDataVersions(ctx context.Context, keep int, before time.Time) (int64, error) {
+	const op = "storage.postgres.PruneDataVersions"
+
+	query := `
+        DELETE FROM PERSONAL_DATA_VERSIONS v
+        USING (
+            SELECT DATA_ID, VERSION, ROW_NUMBER() OVER (PARTITION BY DATA_ID ORDER BY VERSION DESC) AS rn
+            FROM PERSONAL_DATA_VERSIONS
+        ) ranked
+        WHERE v.DATA_ID = ranked.DATA_ID AND v.VERSION = ranked.VERSION
+            AND (($1 > 0 AND ranked.rn > $1) OR v.ARCHIVED_AT < $2)`
+
+	// Нулевое время не отсекает ни одной версии
+	res, err := r.db.ExecContext(ctx, query, keep, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
+
+// archiveVersion copies the record with its attributes to the history if it has the given version.
+func archiveVersion(ctx context.Context, tx *sqlx.Tx, id int64, userID int64, version int64) (bool, error) {
+	query := `
+        INSERT INTO PERSONAL_DATA_VERSIONS (DATA_ID, VERSION, KIND, PDATA, WRAPPED_KEY, METADATA, TAGS, UPDATED_AT)
+        SELECT pd.ID, pd.VERSION, pd.KIND, pd.PDATA, pd.WRAPPED_KEY,
+            COALESCE((SELECT jsonb_object_agg(m.KEY, m.VALUE) FROM PERSONAL_DATA_META m WHERE m.DATA_ID = pd.ID), '{}'),
+            ARRAY(SELECT t.TAG FROM PERSONAL_DATA_TAGS t WHERE t.DATA_ID = pd.ID ORDER BY t.TAG),
+            pd.UPDATED_AT
+        FROM PERSONAL_DATA pd
+        WHERE pd.ID = $1 AND pd.USER_ID = $2 AND pd.VERSION = $3 AND pd.DELETED_AT IS NULL`
+
+	res, err := tx.ExecContext(ctx, query, id, userID, version)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func scanVersion(row scanner) (models.Data, error) {
+	var (
+		data     models.Data
+		metadata []byte
+		tags     pq.StringArray
+	)
+	err := row.Scan(&data.ID, &data.Version, &data.Kind, &data.Payload, &data.WrappedKey, &metadata, &tags, &data.UpdatedAt)
+	if err != nil {
+		return models.Data{}, err
+	}
+	if err = json.Unmarshal(metadata, &data.Metadata); err != nil {
+		return models.Data{}, err
+	}
+	if len(data.Metadata) == 0 {
+		data.Metadata = nil
+	}
+	if len(tags) > 0 {
+		data.Tags = tags
+	}
+	return data, nil
+}
@@ -3,34 +3,104 @@ package repository
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	_ "github.com/lib/pq"
 
 	"github.com/gtngzlv/gophkeeper-server/internal/config"
 	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
 	"github.com/gtngzlv/gophkeeper-server/internal/repository/postgres"
 )
 
 type IRepository interface {
 	Login(ctx context.Context, email string) (models.User, error)
-	Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte) (int64, error)
-	SaveData(ctx context.Context, data models.PersonalData, userID int64) error
+	Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte, recoveryKey []byte, keys models.KeyPair) (int64, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte) error
+	ResetPassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte, recoveryKey []byte) error
+	UpdateEncryptedKey(ctx context.Context, userID int64, encryptedKey []byte) error
+	SetKeyPair(ctx context.Context, userID int64, keys models.KeyPair) error
+	UpdateTOTP(ctx context.Context, userID int64, secret []byte, enabled bool) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	VerifyEmail(ctx context.Context, userID int64, email string) error
+	DeleteUser(ctx context.Context, userID int64, tombstone models.AuditEvent) error
+	SaveData(ctx context.Context, data models.PersonalData, userID int64) ([]int64, error)
+	NewDataIDs(ctx context.Context, n int) ([]int64, error)
+	GetData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	ListData(ctx context.Context, userID int64, opts models.ListOptions) ([]models.Data, error)
+	UpdateData(ctx context.Context, data models.Data, userID int64) (models.Data, error)
+	DeleteData(ctx context.Context, id int64, userID int64, version int64) error
+	ListChanges(ctx context.Context, userID int64, since int64, limit int) ([]models.Data, []models.Tombstone, error)
+	ListUnencryptedData(ctx context.Context, userID int64) ([]models.Data, error)
+	StoreEncryptedData(ctx context.Context, data []models.Data, userID int64) error
+	CreateSession(ctx context.Context, session models.Session) error
+	GetSession(ctx context.Context, id string) (models.Session, error)
+	GetSessionByRefreshHash(ctx context.Context, refreshHash []byte) (models.Session, error)
+	RotateSession(ctx context.Context, id string, oldHash []byte, newHash []byte, wrappedKey []byte, expiresAt time.Time) error
+	ListSessions(ctx context.Context, userID int64) ([]models.Session, error)
+	TouchSession(ctx context.Context, id string, ip string) error
+	RevokeSession(ctx context.Context, id string, userID int64) error
+	CreateAccessToken(ctx context.Context, token models.AccessToken) (models.AccessToken, error)
+	GetAccessToken(ctx context.Context, id string) (models.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID int64) ([]models.AccessToken, error)
+	TouchAccessToken(ctx context.Context, id string) error
+	RevokeAccessToken(ctx context.Context, id string, userID int64) error
+	AddAuditEvent(ctx context.Context, event models.AuditEvent) error
+	CreateUpload(ctx context.Context, upload models.Upload) error
+	GetUpload(ctx context.Context, id string, userID int64) (models.Upload, error)
+	ListExpiredUploads(ctx context.Context) ([]models.Upload, error)
+	AdvanceUpload(ctx context.Context, id string, received int64, newReceived int64, hashState []byte) error
+	CompleteUpload(ctx context.Context, id string, data models.Data, userID int64) (int64, error)
+	DeleteUpload(ctx context.Context, id string) error
+	ListDataVersions(ctx context.Context, id int64, userID int64) ([]models.Data, error)
+	GetDataVersion(ctx context.Context, id int64, userID int64, version int64) (models.Data, error)
+	PruneDataVersions(ctx context.Context, keep int, before time.Time) (int64, error)
+	ListTrash(ctx context.Context, userID int64) ([]models.Data, error)
+	GetTrashedData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	RestoreData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	ListPurgedBlobs(ctx context.Context) ([]string, error)
+	DeletePurgedBlob(ctx context.Context, blobID string) error
+	CreateFolder(ctx context.Context, folder models.FolderData, userID int64) (models.FolderData, error)
+	GetFolder(ctx context.Context, id int64, userID int64) (models.FolderData, error)
+	ListFolders(ctx context.Context, userID int64, parentID int64) ([]models.FolderData, error)
+	ListFolderData(ctx context.Context, userID int64, folderID int64) ([]models.Data, error)
+	RenameFolder(ctx context.Context, id int64, userID int64, name []byte) (models.FolderData, error)
+	MoveFolder(ctx context.Context, id int64, userID int64, parentID int64) (models.FolderData, error)
+	DeleteFolder(ctx context.Context, id int64, userID int64) error
+	MoveData(ctx context.Context, id int64, userID int64, folderID int64) (models.Data, error)
+	SaveShare(ctx context.Context, share models.Share) (models.Share, error)
+	DeleteShare(ctx context.Context, dataID int64, ownerID int64, recipientEmail string) error
+	ListSharedData(ctx context.Context, recipientID int64) ([]models.SharedData, error)
+	GetSharedData(ctx context.Context, dataID int64, recipientID int64) (models.SharedData, error)
+}
+
+// IBlobStore keeps encrypted chunks of files. The database holds only blob IDs and checksums.
+// Postgres, fs and s3 stores implement it.
+type IBlobStore interface {
+	PutChunk(ctx context.Context, blobID string, index int, data []byte) error
+	GetChunk(ctx context.Context, blobID string, index int) ([]byte, error)
+	DeleteBlob(ctx context.Context, blobID string) error
 }
 
 type Repository struct {
 	log *slog.Logger
 	IRepository
+	// IBlobStore keeps chunks in the same database, it serves the "postgres" blob store
+	IBlobStore
 }
 
 func New(ctx context.Context, log *slog.Logger, cfg *config.Config) *Repository {
 	db, err := postgres.New(ctx, log, cfg.DBConnectionPath)
 	if err != nil {
-		log.Error("failed to init db", err.Error())
+		log.Error("failed to init db", logger.Err(err))
 		return nil
 	}
 	return &Repository{
 		log:         log,
 		IRepository: db,
+		IBlobStore:  db,
 	}
 }
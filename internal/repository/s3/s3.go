@@ -0,0 +1,190 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/config"
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+
+	// maxErrorBody limits how much of an error response is read.
+	maxErrorBody = 64 << 10
+)
+
+// S3 keeps chunks as objects <prefix><blobID>/<index> in a bucket of an S3-compatible storage.
+// Buckets are addressed path-style, which works with AWS as well as MinIO and other stand-ins.
+type S3 struct {
+	client          *http.Client
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	prefix          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+// Error is an error response of the storage.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// New returns a new instance of the S3 blob store
+func New(cfg config.S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3 credentials are not configured")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid s3 endpoint scheme %q", endpoint.Scheme)
+	}
+
+	return &S3{
+		client:          &http.Client{Timeout: requestTimeout},
+		endpoint:        endpoint,
+		region:          cfg.Region,
+		bucket:          cfg.Bucket,
+		prefix:          cfg.Prefix,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+	}, nil
+}
+
+func (s *S3) PutChunk(ctx context.Context, blobID string, index int, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, s.chunkKey(blobID, index), nil, data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3) GetChunk(ctx context.Context, blobID string, index int) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.chunkKey(blobID, index), nil, nil)
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, customerr.ErrChunkNotFound
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// DeleteBlob removes all chunks of the blob, missing blobs are ignored.
+func (s *S3) DeleteBlob(ctx context.Context, blobID string) error {
+	keys, err := s.listKeys(ctx, s.prefix+blobID+"/")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// listKeys returns keys of all objects starting with prefix using ListObjectsV2.
+func (s *S3) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{
+		"list-type": {"2"},
+		"prefix":    {prefix},
+	}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: decode list response: %w", err)
+		}
+
+		for _, v := range res.Contents {
+			keys = append(keys, v.Key)
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return keys, nil
+		}
+		query.Set("continuation-token", res.NextContinuationToken)
+	}
+}
+
+// do sends a signed request for the object key, empty key addresses the bucket itself.
+// Responses other than 2xx are returned as *Error.
+func (s *S3) do(ctx context.Context, method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	objectPath := strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		objectPath += "/" + key
+	}
+	u.Path = objectPath
+	u.RawPath = uriEncode(objectPath, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	// Ответ на HEAD и часть ошибок приходят без тела
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if len(data) == 0 || xml.Unmarshal(data, apiErr) != nil {
+		apiErr.Code = http.StatusText(resp.StatusCode)
+	}
+	return nil, apiErr
+}
+
+func (s *S3) chunkKey(blobID string, index int) string {
+	return s.prefix + blobID + "/" + strconv.Itoa(index)
+}
@@ -0,0 +1,196 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/config"
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+)
+
+const testBucket = "keeper"
+
+// fakeS3 is a minimal in-memory stand-in of an S3-compatible storage with path-style buckets.
+// ListObjectsV2 returns pageSize keys per page to exercise continuation tokens.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	pageSize int
+	lists    int
+}
+
+func newFakeS3(t *testing.T, pageSize int) (*fakeS3, *S3) {
+	t.Helper()
+
+	fake := &fakeS3{objects: make(map[string][]byte), pageSize: pageSize}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := New(config.S3Config{
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		Bucket:          testBucket,
+		Prefix:          "blobs/",
+		AccessKeyID:     "minioadmin",
+		SecretAccessKey: "minioadmin",
+	})
+	require.NoError(t, err)
+	return fake, store
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minioadmin/") {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	hash := sha256.Sum256(body)
+	if r.Header.Get("x-amz-content-sha256") != hex.EncodeToString(hash[:]) {
+		writeS3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+testBucket)
+	if path == "" || path == "/" {
+		f.list(w, r)
+		return
+	}
+	key := strings.TrimPrefix(path, "/")
+
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Write(data)
+	case http.MethodHead:
+		// Ответ на HEAD не содержит тела даже при ошибке
+		if _, ok := f.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		writeS3Error(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	f.lists++
+
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, query.Get("prefix")) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	end := min(start+f.pageSize, len(keys))
+
+	type object struct {
+		Key string `xml:"Key"`
+	}
+	res := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []object `xml:"Contents"`
+		IsTruncated           bool     `xml:"IsTruncated"`
+		NextContinuationToken string   `xml:"NextContinuationToken,omitempty"`
+	}{IsTruncated: end < len(keys)}
+	for _, k := range keys[start:end] {
+		res.Contents = append(res.Contents, object{Key: k})
+	}
+	if res.IsTruncated {
+		res.NextContinuationToken = strconv.Itoa(end)
+	}
+	xml.NewEncoder(w).Encode(res)
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: code + " message"})
+}
+
+func TestS3_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	fake, store := newFakeS3(t, 2)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.PutChunk(ctx, "blob-1", i, []byte("chunk "+strconv.Itoa(i))))
+	}
+	require.NoError(t, store.PutChunk(ctx, "blob-2", 0, []byte("other")))
+	assert.Contains(t, fake.objects, "blobs/blob-1/3")
+
+	data, err := store.GetChunk(ctx, "blob-1", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("chunk 3"), data)
+
+	_, err = store.GetChunk(ctx, "blob-1", 7)
+	require.ErrorIs(t, err, customerr.ErrChunkNotFound)
+
+	// Пять чанков при двух ключах на страницу требуют трёх запросов списка
+	require.NoError(t, store.DeleteBlob(ctx, "blob-1"))
+	assert.Equal(t, 3, fake.lists)
+	assert.Len(t, fake.objects, 1)
+	assert.Contains(t, fake.objects, "blobs/blob-2/0")
+
+	_, err = store.GetChunk(ctx, "blob-1", 0)
+	require.ErrorIs(t, err, customerr.ErrChunkNotFound)
+
+	// Удаление отсутствующего блоба не ошибка
+	require.NoError(t, store.DeleteBlob(ctx, "blob-1"))
+}
+
+func TestS3_ErrorResponses(t *testing.T) {
+	ctx := context.Background()
+	_, store := newFakeS3(t, 1000)
+
+	store.accessKeyID = "intruder"
+	err := store.PutChunk(ctx, "blob", 0, []byte("data"))
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "AccessDenied", apiErr.Code)
+	assert.Equal(t, "AccessDenied message", apiErr.Message)
+
+	store.accessKeyID = "minioadmin"
+	_, err = store.do(ctx, http.MethodHead, store.chunkKey("blob", 0), nil, nil)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "Not Found", apiErr.Code)
+	assert.Empty(t, apiErr.Message)
+}
@@ -0,0 +1,201 @@
+package gophkeeper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+const (
+	accessTokenIDSize = 8
+
+	// defaultAccessTokenTTL is used when the lifetime of a new access token is not set.
+	defaultAccessTokenTTL = 90 * 24 * time.Hour
+
+	// accessTokenKeyPrefix separates keyring entries of access tokens from login sessions.
+	accessTokenKeyPrefix = "pat:"
+)
+
+// CreateAccessToken issues a personal access token of the current user. The token is returned only once,
+// the user secret key is stored wrapped by it, so the token can decrypt records without the password.
+func (s *Service) CreateAccessToken(ctx context.Context, name string, scope models.AccessScope, ttl time.Duration) (string, models.AccessToken, error) {
+	const op = "service.Auth.CreateAccessToken"
+
+	if ttl == 0 {
+		ttl = defaultAccessTokenTTL
+	}
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return "", models.AccessToken{}, err
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	rawID := make([]byte, accessTokenIDSize)
+	if _, err = rand.Read(rawID); err != nil {
+		log.Error("failed to generate token id", logger.Err(err))
+		return "", models.AccessToken{}, fmt.Errorf("%s:%w", op, err)
+	}
+	id := hex.EncodeToString(rawID)
+
+	secret, err := newTokenSecret()
+	if err != nil {
+		log.Error("failed to generate token secret", logger.Err(err))
+		return "", models.AccessToken{}, fmt.Errorf("%s:%w", op, err)
+	}
+	key, err := tokenSecretKey(secret)
+	if err != nil {
+		return "", models.AccessToken{}, fmt.Errorf("%s:%w", op, err)
+	}
+	wrappedKey, err := seal(key, secretKey, []byte(id))
+	if err != nil {
+		log.Error("failed to wrap secret key", logger.Err(err))
+		return "", models.AccessToken{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	created, err := s.storage.CreateAccessToken(ctx, models.AccessToken{
+		ID:         id,
+		UserID:     userID,
+		Name:       name,
+		SecretHash: hashTokenSecret(secret),
+		WrappedKey: wrappedKey,
+		Scope:      scope,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		log.Error("failed to store access token", logger.Err(err))
+		return "", models.AccessToken{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("access token created", slog.String("id", id))
+	return core.AccessTokenPrefix + id + "_" + secret, created, nil
+}
+
+// ListAccessTokens returns not revoked access tokens of the current user.
+func (s *Service) ListAccessTokens(ctx context.Context) ([]models.AccessToken, error) {
+	const op = "service.Auth.ListAccessTokens"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return nil, customerr.ErrFailedGetUserID
+	}
+
+	tokens, err := s.storage.ListAccessTokens(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list access tokens", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return tokens, nil
+}
+
+// RevokeAccessToken makes the access token of the current user invalid immediately.
+func (s *Service) RevokeAccessToken(ctx context.Context, id string) error {
+	const op = "service.Auth.RevokeAccessToken"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+
+	if err := s.storage.RevokeAccessToken(ctx, id, userID); err != nil {
+		if !errors.Is(err, customerr.ErrAccessTokenNotFound) {
+			s.logger.Error("failed to revoke access token", slog.String("op", op), logger.Err(err))
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	s.keyring.Delete(accessTokenKeyPrefix + id)
+	return nil
+}
+
+// ValidateAccessToken checks personal access token and unlocks the user secret key for the request.
+func (s *Service) ValidateAccessToken(ctx context.Context, token string) (*core.Claims, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, core.AccessTokenPrefix), "_")
+	if !ok {
+		return nil, customerr.ErrInvalidToken
+	}
+
+	pat, err := s.storage.GetAccessToken(ctx, id)
+	if err != nil {
+		if errors.Is(err, customerr.ErrAccessTokenNotFound) {
+			return nil, customerr.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !pat.Active(now) || !compareHashes(pat.SecretHash, hashTokenSecret(secret)) {
+		return nil, customerr.ErrInvalidToken
+	}
+
+	sessionID := accessTokenKeyPrefix + id
+	if _, ok := s.keyring.Get(sessionID, pat.UserID); !ok {
+		key, err := tokenSecretKey(secret)
+		if err != nil {
+			return nil, customerr.ErrInvalidToken
+		}
+		secretKey, err := open(key, pat.WrappedKey, []byte(id))
+		if err != nil {
+			return nil, customerr.ErrInvalidToken
+		}
+		s.keyring.Put(sessionID, pat.UserID, secretKey, min(s.tokenTTL, pat.ExpiresAt.Sub(now)))
+	}
+
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) > lastSeenPrecision {
+		if err = s.storage.TouchAccessToken(ctx, id); err != nil {
+			s.logger.Error("failed to touch access token", logger.Err(err))
+		}
+	}
+
+	return &core.Claims{
+		UserID:    pat.UserID,
+		SessionID: sessionID,
+		Scope:     &pat.Scope,
+	}, nil
+}
+
+// scopeTag returns the tag the request is limited to by its access token, empty if it is not limited.
+func scopeTag(ctx context.Context) string {
+	scope, _ := core.GetContextAccessScope(ctx)
+	return scope.Tag
+}
+
+// inScope reports whether the record is visible to the access token of the request.
+func inScope(ctx context.Context, data models.Data) bool {
+	tag := scopeTag(ctx)
+	if tag == "" {
+		return true
+	}
+	for _, t := range data.Attributes.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// withScopeTag adds the tag of the access token to attrs, so records written by the token stay visible to it.
+func withScopeTag(ctx context.Context, attrs models.Attributes) models.Attributes {
+	tag := scopeTag(ctx)
+	if tag == "" {
+		return attrs
+	}
+	for _, t := range attrs.Tags {
+		if t == tag {
+			return attrs
+		}
+	}
+	attrs.Tags = append(append([]string(nil), attrs.Tags...), tag)
+	return attrs
+}
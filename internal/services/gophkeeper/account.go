@@ -0,0 +1,53 @@
+package gophkeeper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// DeleteAccount removes the current user with all stored data after checking the password again.
+// Sessions, access tokens and unlocked keys of the user stop working immediately,
+// file content is removed by the background job.
+func (s *Service) DeleteAccount(ctx context.Context, password string) error {
+	const op = "service.Auth.DeleteAccount"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if _, err = unlockSecretKey(user, password); err != nil {
+		log.Info("invalid credentials", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+	}
+
+	tombstone := models.AuditEvent{
+		Event: models.AuditAccountDeleted,
+		IP:    core.GetContextClientIP(ctx),
+	}
+	if err = s.storage.DeleteUser(ctx, userID, tombstone); err != nil {
+		log.Error("failed to delete user", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	s.keyring.DeleteUser(userID)
+	s.limits.Account.Reset(accountKey(user.Email))
+
+	log.Info("account deleted")
+	return nil
+}
@@ -0,0 +1,378 @@
+package gophkeeper
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+const (
+	// blobChunkSize is the size of every chunk of a file except the last one.
+	blobChunkSize = 1 << 20
+
+	blobIDSize = 16
+
+	// uploadTTL is how long an unfinished upload can be resumed.
+	uploadTTL = 24 * time.Hour
+)
+
+// uploadMeta is kept encrypted in the upload until the file record is created.
+type uploadMeta struct {
+	Name       string            `json:"name"`
+	SHA256     string            `json:"sha256"`
+	Attributes models.Attributes `json:"attributes"`
+}
+
+// CreateUpload starts a resumable upload of a file of size bytes with the expected SHA-256 checksum.
+// Content is sent with WriteChunk, the file record is created by CompleteUpload.
+func (s *Service) CreateUpload(ctx context.Context, name string, size int64, checksum string, attrs models.Attributes) (models.Upload, error) {
+	const op = "service.Keeper.CreateUpload"
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	rawID := make([]byte, blobIDSize)
+	if _, err = rand.Read(rawID); err != nil {
+		log.Error("failed to generate upload id", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	id := hex.EncodeToString(rawID)
+
+	dataKey, wrappedKey, err := newDataKey(secretKey)
+	if err != nil {
+		log.Error("failed to generate data key", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	plaintext, err := json.Marshal(uploadMeta{
+		Name:       name,
+		SHA256:     strings.ToLower(checksum),
+		Attributes: withScopeTag(ctx, attrs),
+	})
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	meta, err := seal(dataKey, plaintext, []byte(id))
+	if err != nil {
+		log.Error("failed to encrypt upload", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	hashState, err := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	upload := models.Upload{
+		ID:         id,
+		UserID:     userID,
+		Size:       size,
+		WrappedKey: wrappedKey,
+		Meta:       meta,
+		HashState:  hashState,
+		ChunkSize:  blobChunkSize,
+		ExpiresAt:  time.Now().Add(uploadTTL),
+	}
+	if err = s.storage.CreateUpload(ctx, upload); err != nil {
+		log.Error("failed to create upload", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return upload, nil
+}
+
+// GetUpload returns progress of an unfinished upload of the current user.
+func (s *Service) GetUpload(ctx context.Context, id string) (models.Upload, error) {
+	const op = "service.Keeper.GetUpload"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return models.Upload{}, customerr.ErrFailedGetUserID
+	}
+
+	upload, err := s.storage.GetUpload(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrUploadNotFound) {
+			s.logger.Error("failed to get upload", slog.String("op", op), logger.Err(err))
+		}
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return upload, nil
+}
+
+// WriteChunk encrypts and stores the next chunk of the upload. The chunk must start at the received offset
+// and have the chunk size of the upload, only the last one may be shorter.
+func (s *Service) WriteChunk(ctx context.Context, id string, offset int64, chunk []byte) (models.Upload, error) {
+	const op = "service.Keeper.WriteChunk"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.String("uploadID", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	upload, err := s.storage.GetUpload(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrUploadNotFound) {
+			log.Error("failed to get upload", logger.Err(err))
+		}
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if offset != upload.Received {
+		return models.Upload{}, fmt.Errorf("%s:%w", op, customerr.ErrUploadOffset)
+	}
+	received := upload.Received + int64(len(chunk))
+	if len(chunk) == 0 || received > upload.Size || (len(chunk) != upload.ChunkSize && received != upload.Size) {
+		return models.Upload{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidChunk)
+	}
+
+	hash := sha256.New()
+	if err = hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(upload.HashState); err != nil {
+		log.Error("failed to restore checksum state", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	hash.Write(chunk)
+	hashState, err := hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	dataKey, err := open(secretKey, upload.WrappedKey, nil)
+	if err != nil {
+		log.Error("failed to unwrap data key", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+	index := upload.Chunks()
+	sealed, err := seal(dataKey, chunk, chunkAD(upload.ID, index))
+	if err != nil {
+		log.Error("failed to encrypt chunk", logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = s.blobs.PutChunk(ctx, upload.ID, index, sealed); err != nil {
+		log.Error("failed to store chunk", slog.Int("index", index), logger.Err(err))
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Прогресс сохраняется только после записи чанка, прерванную загрузку можно продолжить с received
+	if err = s.storage.AdvanceUpload(ctx, upload.ID, upload.Received, received, hashState); err != nil {
+		if !errors.Is(err, customerr.ErrUploadOffset) {
+			log.Error("failed to advance upload", logger.Err(err))
+		}
+		return models.Upload{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	upload.Received = received
+	upload.HashState = hashState
+	return upload, nil
+}
+
+// CompleteUpload checks the checksum of the received content and turns the upload into a file record.
+// On mismatch the upload is discarded and ErrChecksumMismatch is returned.
+func (s *Service) CompleteUpload(ctx context.Context, id string) (models.Record, error) {
+	const op = "service.Keeper.CompleteUpload"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.String("uploadID", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	upload, err := s.storage.GetUpload(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrUploadNotFound) {
+			log.Error("failed to get upload", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if upload.Received != upload.Size {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrUploadIncomplete)
+	}
+
+	dataKey, err := open(secretKey, upload.WrappedKey, nil)
+	if err != nil {
+		log.Error("failed to unwrap data key", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	plaintext, err := open(dataKey, upload.Meta, []byte(upload.ID))
+	if err != nil {
+		log.Error("failed to decrypt upload", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	var meta uploadMeta
+	if err = json.Unmarshal(plaintext, &meta); err != nil {
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	hash := sha256.New()
+	if err = hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(upload.HashState); err != nil {
+		log.Error("failed to restore checksum state", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != meta.SHA256 {
+		log.Info("checksum mismatch")
+		s.removeUpload(ctx, log, upload.ID)
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrChecksumMismatch)
+	}
+
+	secret := models.Secret{
+		Kind: models.SecretFile,
+		File: &models.FileRef{
+			Name:      meta.Name,
+			Size:      upload.Size,
+			SHA256:    meta.SHA256,
+			ChunkSize: upload.ChunkSize,
+		},
+	}
+	// Ключ загрузки не привязан к записи, для записи он оборачивается заново с её ID
+	ids, err := s.storage.NewDataIDs(ctx, 1)
+	if err != nil {
+		log.Error("failed to reserve data id", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	wrappedKey, err := wrapDataKey(secretKey, userID, ids[0], dataKey)
+	if err != nil {
+		log.Error("failed to wrap data key", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	data, err := sealData(dataKey, wrappedKey, secret, meta.Attributes)
+	if err != nil {
+		log.Error("failed to encrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	data.ID = ids[0]
+	data.BlobID = upload.ID
+
+	dataID, err := s.storage.CompleteUpload(ctx, upload.ID, data, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrUploadNotFound) {
+			log.Error("failed to complete upload", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+
+	stored, err := s.storage.GetData(ctx, dataID, userID)
+	if err != nil {
+		log.Error("failed to get data", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	record, err := openData(secretKey, userID, stored)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return record, nil
+}
+
+// ReadChunk returns the decrypted chunk of the file record content.
+func (s *Service) ReadChunk(ctx context.Context, id int64, index int) ([]byte, error) {
+	const op = "service.Keeper.ReadChunk"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	if !inScope(ctx, data) || data.BlobID == "" {
+		return nil, fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+	}
+
+	dataKey, err := unwrapDataKey(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to unwrap data key", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	sealed, err := s.blobs.GetChunk(ctx, data.BlobID, index)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrChunkNotFound) {
+			log.Error("failed to get chunk", slog.Int("index", index), logger.Err(err))
+		}
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	chunk, err := open(dataKey, sealed, chunkAD(data.BlobID, index))
+	if err != nil {
+		log.Error("failed to decrypt chunk", slog.Int("index", index), logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return chunk, nil
+}
+
+// RemoveExpiredUploads deletes abandoned uploads of all users with their chunks
+// and returns the number of removed uploads. Uploads which fail to be removed are retried on the next run.
+func (s *Service) RemoveExpiredUploads(ctx context.Context) (int64, error) {
+	const op = "service.Keeper.RemoveExpiredUploads"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	expired, err := s.storage.ListExpiredUploads(ctx)
+	if err != nil {
+		log.Error("failed to list expired uploads", logger.Err(err))
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	var removed int64
+	for _, upload := range expired {
+		if s.removeUpload(ctx, log, upload.ID) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// removeUpload deletes the upload and its chunks and reports whether it succeeded, errors are only logged.
+// The upload is kept until its chunks are gone, so a failed removal can be retried.
+func (s *Service) removeUpload(ctx context.Context, log *slog.Logger, id string) bool {
+	if err := s.blobs.DeleteBlob(ctx, id); err != nil {
+		log.Error("failed to delete upload chunks", slog.String("uploadID", id), logger.Err(err))
+		return false
+	}
+	if err := s.storage.DeleteUpload(ctx, id); err != nil {
+		log.Error("failed to delete upload", slog.String("uploadID", id), logger.Err(err))
+		return false
+	}
+	return true
+}
+
+// chunkAD binds an encrypted chunk to its blob and position, so chunks can not be swapped or reordered.
+func chunkAD(blobID string, index int) []byte {
+	return []byte(blobID + ":" + strconv.Itoa(index))
+}
@@ -0,0 +1,178 @@
+package gophkeeper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	keySize         = 32
+	tokenSecretSize = 32
+)
+
+var errCiphertextTooShort = errors.New("ciphertext too short")
+
+func generateSecretKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func hashSecretKey(key []byte) string {
+	hash := sha256.Sum256(key)
+	return string(hash[:])
+}
+
+// encryptSecretKey шифрует секретный ключ ключом, выведенным из пароля текущей версией KDF.
+func encryptSecretKey(key []byte, password []byte) ([]byte, error) {
+	params := currentKDFParams
+	params.salt = make([]byte, kdfSaltSize)
+	if _, err := rand.Read(params.salt); err != nil {
+		return nil, err
+	}
+
+	header := params.marshal()
+	ciphertext, err := seal(params.deriveKey(password), key, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, ciphertext...), nil
+}
+
+// decryptSecretKey расшифровывает секретный ключ на основе пароля.
+func decryptSecretKey(ciphertext []byte, password []byte) ([]byte, error) {
+	if isLegacySecretKey(ciphertext) {
+		// Преобразование пароля в ключ с использованием хеш-функции
+		hashedPassword := sha256.Sum256(password)
+
+		return open(hashedPassword[:], ciphertext, nil)
+	}
+
+	params, err := unmarshalKDFParams(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	header := ciphertext[:kdfHeaderSize]
+	return open(params.deriveKey(password), ciphertext[kdfHeaderSize:], header)
+}
+
+// seal encrypts plaintext with AES-256-GCM under key and returns nonce followed by ciphertext.
+// additionalData is authenticated but not encrypted, the same value must be passed to open.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts ciphertext produced by seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errCiphertextTooShort
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+	ciphertext = ciphertext[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealString encrypts s and encodes the result for storing in text columns.
+func sealString(key []byte, s string, additionalData []byte) (string, error) {
+	ciphertext, err := seal(key, []byte(s), additionalData)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// openString decrypts value produced by sealString.
+func openString(key []byte, s string, additionalData []byte) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(key, ciphertext, additionalData)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// compareHashes сравнивает два хеша без раскрывания конкретного значения.
+func compareHashes(hash1, hash2 []byte) bool {
+	return subtle.ConstantTimeCompare(hash1, hash2) == 1
+}
+
+// newSessionID returns random identifier of an authenticated session.
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// newTokenSecret returns random secret of a refresh or personal access token.
+func newTokenSecret() (string, error) {
+	token := make([]byte, tokenSecretSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// hashTokenSecret returns the value stored instead of the token secret.
+func hashTokenSecret(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// tokenSecretKey derives the key wrapping the user secret key from a token secret.
+// The secret is random, so a slow password KDF is not needed here.
+func tokenSecretKey(token string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != tokenSecretSize {
+		return nil, errors.New("invalid token secret size")
+	}
+
+	key := make([]byte, keySize)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, raw, nil, []byte("gophkeeper session key")), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
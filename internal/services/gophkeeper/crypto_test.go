@@ -0,0 +1,103 @@
+package gophkeeper
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyEnvelope encrypts secretKey the way it was done before Argon2id: with unsalted SHA-256 of the password.
+func legacyEnvelope(t *testing.T, secretKey []byte, password string) []byte {
+	t.Helper()
+
+	hashedPassword := sha256.Sum256([]byte(password))
+	envelope, err := seal(hashedPassword[:], secretKey, nil)
+	require.NoError(t, err)
+	require.True(t, isLegacySecretKey(envelope))
+	return envelope
+}
+
+func TestSeal_AdditionalData(t *testing.T) {
+	key, err := generateSecretKey()
+	require.NoError(t, err)
+
+	ciphertext, err := seal(key, []byte("secret"), []byte("record"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	plaintext, err := open(key, ciphertext, []byte("record"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = open(key, ciphertext, []byte("another"))
+	assert.Error(t, err)
+	_, err = open(key, ciphertext, nil)
+	assert.Error(t, err)
+	_, err = open(key, ciphertext[:4], []byte("record"))
+	assert.ErrorIs(t, err, errCiphertextTooShort)
+}
+
+func TestEncryptSecretKey(t *testing.T) {
+	withKDFParams(t, testKDFParams)
+
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+
+	envelope, err := encryptSecretKey(secretKey, []byte("password"))
+	require.NoError(t, err)
+	assert.Equal(t, kdfVersionArgon2id, envelope[0])
+	assert.NotContains(t, string(envelope), string(secretKey))
+
+	decrypted, err := decryptSecretKey(envelope, []byte("password"))
+	require.NoError(t, err)
+	assert.Equal(t, secretKey, decrypted)
+
+	_, err = decryptSecretKey(envelope, []byte("wrong"))
+	assert.Error(t, err)
+
+	// Соль случайная, одинаковые ключ и пароль дают разные конверты
+	again, err := encryptSecretKey(secretKey, []byte("password"))
+	require.NoError(t, err)
+	assert.NotEqual(t, envelope[:kdfHeaderSize], again[:kdfHeaderSize])
+}
+
+func TestDecryptSecretKey_HeaderTampering(t *testing.T) {
+	withKDFParams(t, testKDFParams)
+
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	envelope, err := encryptSecretKey(secretKey, []byte("password"))
+	require.NoError(t, err)
+
+	params, err := unmarshalKDFParams(envelope)
+	require.NoError(t, err)
+	key := params.deriveKey([]byte("password"))
+
+	// time, memory, threads и соль, значения остаются в допустимых пределах
+	for _, offset := range []int{4, 8, 9, 10, kdfHeaderSize - 1} {
+		tampered := append([]byte(nil), envelope...)
+		tampered[offset] ^= 1
+
+		_, err = decryptSecretKey(tampered, []byte("password"))
+		assert.Error(t, err, "offset %d", offset)
+
+		// Даже с правильным ключом изменённый заголовок не проходит проверку
+		_, err = open(key, envelope[kdfHeaderSize:], tampered[:kdfHeaderSize])
+		assert.Error(t, err, "offset %d", offset)
+	}
+}
+
+func TestDecryptSecretKey_Legacy(t *testing.T) {
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	envelope := legacyEnvelope(t, secretKey, "password")
+
+	decrypted, err := decryptSecretKey(envelope, []byte("password"))
+	require.NoError(t, err)
+	assert.Equal(t, secretKey, decrypted)
+
+	_, err = decryptSecretKey(envelope, []byte("wrong"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,276 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// SaveData stores every secret as a separate record of the current user with the same attributes
+// and returns their IDs.
+func (s *Service) SaveData(ctx context.Context, secrets []models.Secret, attrs models.Attributes) ([]int64, error) {
+	const op = "service.Keeper.SaveData"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs = withScopeTag(ctx, attrs)
+
+	for _, secret := range secrets {
+		if secret.Kind == models.SecretFile {
+			return nil, fmt.Errorf("%s:%w", op, customerr.ErrFileRecord)
+		}
+	}
+
+	// ID записи нужен до вставки, ключ данных привязывается к нему
+	ids, err := s.storage.NewDataIDs(ctx, len(secrets))
+	if err != nil {
+		log.Error("failed to reserve data ids", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	data := models.PersonalData{PData: make([]models.Data, 0, len(secrets))}
+	for i, secret := range secrets {
+		dataKey, wrappedKey, err := newRecordKey(secretKey, userID, ids[i])
+		if err != nil {
+			log.Error("failed to generate data key", logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		sealed, err := sealData(dataKey, wrappedKey, secret, attrs)
+		if err != nil {
+			log.Error("failed to encrypt secret", logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		sealed.ID = ids[i]
+		data.PData = append(data.PData, sealed)
+	}
+
+	ids, err = s.storage.SaveData(ctx, data, userID)
+	if err != nil {
+		log.Error("failed to save data", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+	return ids, nil
+}
+
+// GetData returns a single record of the current user.
+func (s *Service) GetData(ctx context.Context, id int64) (models.Record, error) {
+	const op = "service.Keeper.GetData"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	data, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if !inScope(ctx, data) {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+	}
+
+	record, err := openData(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return record, nil
+}
+
+// ListData returns a page of the current user records and the cursor of the next page.
+// Cursor is zero when there are no more records.
+func (s *Service) ListData(ctx context.Context, opts models.ListOptions) ([]models.Record, int64, error) {
+	const op = "service.Keeper.ListData"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if tag := scopeTag(ctx); tag != "" {
+		if opts.Tag != "" && opts.Tag != tag {
+			return nil, 0, nil
+		}
+		opts.Tag = tag
+	}
+
+	limit := opts.Limit
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	opts.Limit++
+	data, err := s.storage.ListData(ctx, userID, opts)
+	if err != nil {
+		log.Error("failed to list data", logger.Err(err))
+		return nil, 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	var next int64
+	if len(data) > limit {
+		data = data[:limit]
+		next = data[limit-1].ID
+	}
+
+	records := make([]models.Record, 0, len(data))
+	for _, v := range data {
+		record, err := openData(secretKey, userID, v)
+		if err != nil {
+			log.Error("failed to decrypt secret", slog.Int64("id", v.ID), logger.Err(err))
+			return nil, 0, fmt.Errorf("%s:%w", op, err)
+		}
+		records = append(records, record)
+	}
+	return records, next, nil
+}
+
+// UpdateData replaces secret and attributes of the current user record if it still has the expected version.
+// Otherwise ConflictError with the current record is returned.
+func (s *Service) UpdateData(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error) {
+	const op = "service.Keeper.UpdateData"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	current, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if !inScope(ctx, current) {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+	}
+	if current.Kind == models.SecretFile || secret.Kind == models.SecretFile {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrFileRecord)
+	}
+	if current.Version != version {
+		return models.Record{}, fmt.Errorf("%s:%w", op, s.conflict(secretKey, userID, current))
+	}
+	attrs = withScopeTag(ctx, attrs)
+
+	// Ключ записи не меняется при обновлении, новый создаётся только для незашифрованных записей
+	dataKey, wrappedKey, err := recordDataKey(secretKey, userID, current)
+	if err != nil {
+		log.Error("failed to get data key", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	sealed, err := sealData(dataKey, wrappedKey, secret, attrs)
+	if err != nil {
+		log.Error("failed to encrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	sealed.ID = id
+	sealed.Version = version
+
+	data, err := s.storage.UpdateData(ctx, sealed, userID)
+	if err != nil {
+		if errors.Is(err, customerr.ErrVersionConflict) {
+			// Запись изменили между чтением и записью
+			return models.Record{}, fmt.Errorf("%s:%w", op, s.reloadConflict(ctx, secretKey, id, userID))
+		}
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to update data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+
+	record, err := openData(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return record, nil
+}
+
+// DeleteData moves the current user record to the trash if it still has the expected version.
+// Otherwise ConflictError with the current record is returned.
+func (s *Service) DeleteData(ctx context.Context, id int64, version int64) error {
+	const op = "service.Keeper.DeleteData"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+
+	current, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if !inScope(ctx, current) {
+		return fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+	}
+
+	if err = s.storage.DeleteData(ctx, id, userID, version); err != nil {
+		if errors.Is(err, customerr.ErrVersionConflict) {
+			// Удаление не требует ключа, текущую копию отдаём, только если сессия его держит
+			if _, secretKey, keyErr := s.sessionKey(ctx); keyErr == nil {
+				err = s.reloadConflict(ctx, secretKey, id, userID)
+			}
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to delete data", logger.Err(err))
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+	return nil
+}
+
+// conflict returns ConflictError with the decrypted current record,
+// or plain ErrVersionConflict when the record can not be decrypted.
+func (s *Service) conflict(secretKey []byte, userID int64, current models.Data) error {
+	record, err := openData(secretKey, userID, current)
+	if err != nil {
+		s.logger.Error("failed to decrypt secret", slog.Int64("id", current.ID), logger.Err(err))
+		return customerr.ErrVersionConflict
+	}
+	return &customerr.ConflictError{Current: record}
+}
+
+// reloadConflict loads the record changed concurrently and returns ConflictError with it.
+func (s *Service) reloadConflict(ctx context.Context, secretKey []byte, id int64, userID int64) error {
+	current, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		// Запись успели удалить
+		return err
+	}
+	return s.conflict(secretKey, userID, current)
+}
@@ -0,0 +1,267 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// folderNameAD binds encrypted folder names to their purpose.
+var folderNameAD = []byte("folder")
+
+// CreateFolder creates a folder of the current user inside parentID, zero parentID creates a top-level folder.
+func (s *Service) CreateFolder(ctx context.Context, name string, parentID int64) (models.Folder, error) {
+	const op = "service.Keeper.CreateFolder"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Folder{}, err
+	}
+
+	dataKey, wrappedKey, err := newDataKey(secretKey)
+	if err != nil {
+		log.Error("failed to generate data key", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	sealed, err := seal(dataKey, []byte(name), folderNameAD)
+	if err != nil {
+		log.Error("failed to encrypt folder name", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	folder, err := s.storage.CreateFolder(ctx, models.FolderData{
+		ParentID:   parentID,
+		Name:       sealed,
+		WrappedKey: wrappedKey,
+	}, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrFolderNotFound) {
+			log.Error("failed to create folder", logger.Err(err))
+		}
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	res, err := openFolder(secretKey, folder)
+	if err != nil {
+		log.Error("failed to decrypt folder", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return res, nil
+}
+
+// RenameFolder sets a new name of the current user folder.
+func (s *Service) RenameFolder(ctx context.Context, id int64, name string) (models.Folder, error) {
+	const op = "service.Keeper.RenameFolder"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Folder{}, err
+	}
+
+	current, err := s.storage.GetFolder(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrFolderNotFound) {
+			log.Error("failed to get folder", logger.Err(err))
+		}
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	dataKey, err := open(secretKey, current.WrappedKey, nil)
+	if err != nil {
+		log.Error("failed to unwrap folder key", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	sealed, err := seal(dataKey, []byte(name), folderNameAD)
+	if err != nil {
+		log.Error("failed to encrypt folder name", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	folder, err := s.storage.RenameFolder(ctx, id, userID, sealed)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrFolderNotFound) {
+			log.Error("failed to rename folder", logger.Err(err))
+		}
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+	res, err := openFolder(secretKey, folder)
+	if err != nil {
+		log.Error("failed to decrypt folder", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return res, nil
+}
+
+// MoveFolder moves the current user folder with all its contents into parentID,
+// zero parentID makes it top-level.
+func (s *Service) MoveFolder(ctx context.Context, id int64, parentID int64) (models.Folder, error) {
+	const op = "service.Keeper.MoveFolder"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Folder{}, err
+	}
+
+	folder, err := s.storage.MoveFolder(ctx, id, userID, parentID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrFolderNotFound) && !errors.Is(err, customerr.ErrFolderCycle) {
+			log.Error("failed to move folder", logger.Err(err))
+		}
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+	res, err := openFolder(secretKey, folder)
+	if err != nil {
+		log.Error("failed to decrypt folder", logger.Err(err))
+		return models.Folder{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return res, nil
+}
+
+// DeleteFolder removes an empty folder of the current user.
+func (s *Service) DeleteFolder(ctx context.Context, id int64) error {
+	const op = "service.Keeper.DeleteFolder"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, _, err := s.sessionKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = s.storage.DeleteFolder(ctx, id, userID); err != nil {
+		if !errors.Is(err, customerr.ErrFolderNotFound) && !errors.Is(err, customerr.ErrFolderNotEmpty) {
+			log.Error("failed to delete folder", logger.Err(err))
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+	return nil
+}
+
+// ListFolder returns child folders and records of the current user folder, zero id lists the top level.
+func (s *Service) ListFolder(ctx context.Context, id int64) (models.FolderContents, error) {
+	const op = "service.Keeper.ListFolder"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.FolderContents{}, err
+	}
+
+	var contents models.FolderContents
+	if id != 0 {
+		folder, err := s.storage.GetFolder(ctx, id, userID)
+		if err != nil {
+			if !errors.Is(err, customerr.ErrFolderNotFound) {
+				log.Error("failed to get folder", logger.Err(err))
+			}
+			return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+		}
+		if contents.Folder, err = openFolder(secretKey, folder); err != nil {
+			log.Error("failed to decrypt folder", logger.Err(err))
+			return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	folders, err := s.storage.ListFolders(ctx, userID, id)
+	if err != nil {
+		log.Error("failed to list folders", logger.Err(err))
+		return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+	}
+	contents.Folders = make([]models.Folder, 0, len(folders))
+	for _, v := range folders {
+		folder, err := openFolder(secretKey, v)
+		if err != nil {
+			log.Error("failed to decrypt folder", slog.Int64("folderID", v.ID), logger.Err(err))
+			return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+		}
+		contents.Folders = append(contents.Folders, folder)
+	}
+
+	data, err := s.storage.ListFolderData(ctx, userID, id)
+	if err != nil {
+		log.Error("failed to list folder records", logger.Err(err))
+		return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+	}
+	contents.Records = make([]models.Record, 0, len(data))
+	for _, v := range data {
+		record, err := openData(secretKey, userID, v)
+		if err != nil {
+			log.Error("failed to decrypt secret", slog.Int64("recordID", v.ID), logger.Err(err))
+			return models.FolderContents{}, fmt.Errorf("%s:%w", op, err)
+		}
+		contents.Records = append(contents.Records, record)
+	}
+	return contents, nil
+}
+
+// MoveRecord puts the current user record into the folder, zero folderID moves it out of folders.
+func (s *Service) MoveRecord(ctx context.Context, id int64, folderID int64) (models.Record, error) {
+	const op = "service.Keeper.MoveRecord"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	data, err := s.storage.MoveData(ctx, id, userID, folderID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) && !errors.Is(err, customerr.ErrFolderNotFound) {
+			log.Error("failed to move data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+
+	record, err := openData(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return record, nil
+}
+
+// openFolder decrypts the name of a stored folder with the owner secret key.
+func openFolder(secretKey []byte, folder models.FolderData) (models.Folder, error) {
+	dataKey, err := open(secretKey, folder.WrappedKey, nil)
+	if err != nil {
+		return models.Folder{}, fmt.Errorf("unwrap folder key: %w", err)
+	}
+	name, err := open(dataKey, folder.Name, folderNameAD)
+	if err != nil {
+		return models.Folder{}, fmt.Errorf("decrypt folder name: %w", err)
+	}
+	return models.Folder{
+		ID:        folder.ID,
+		ParentID:  folder.ParentID,
+		Name:      string(name),
+		CreatedAt: folder.CreatedAt,
+		UpdatedAt: folder.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package gophkeeper
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Encrypted secret key envelope:
+//
+//	version (1) | time (4) | memory KiB (4) | threads (1) | salt (16) | nonce | ciphertext
+//
+// The header is authenticated as additional data, so parameters can't be downgraded.
+// Legacy envelopes have no header: nonce | ciphertext, the key is an unsalted SHA-256 of the password.
+const (
+	kdfVersionArgon2id byte = 1
+
+	kdfSaltSize   = 16
+	kdfHeaderSize = 1 + 4 + 4 + 1 + kdfSaltSize
+
+	// nonce (12) + key (32) + tag (16)
+	legacySecretKeySize = 12 + keySize + 16
+
+	maxKDFTime    = 16
+	maxKDFMemory  = 1024 * 1024
+	maxKDFThreads = 64
+)
+
+var (
+	errUnknownKDFVersion = errors.New("unknown kdf version")
+	errInvalidKDFParams  = errors.New("invalid kdf params")
+)
+
+// currentKDFParams are used for all new and upgraded secret keys, see RFC 9106 section 4.
+var currentKDFParams = kdfParams{
+	version: kdfVersionArgon2id,
+	time:    3,
+	memory:  64 * 1024,
+	threads: 4,
+}
+
+type kdfParams struct {
+	version byte
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+}
+
+func (p kdfParams) deriveKey(password []byte) []byte {
+	return argon2.IDKey(password, p.salt, p.time, p.memory, p.threads, keySize)
+}
+
+func (p kdfParams) marshal() []byte {
+	header := make([]byte, 0, kdfHeaderSize)
+	header = append(header, p.version)
+	header = binary.BigEndian.AppendUint32(header, p.time)
+	header = binary.BigEndian.AppendUint32(header, p.memory)
+	header = append(header, p.threads)
+	return append(header, p.salt...)
+}
+
+func unmarshalKDFParams(envelope []byte) (kdfParams, error) {
+	if len(envelope) < kdfHeaderSize {
+		return kdfParams{}, errCiphertextTooShort
+	}
+	if envelope[0] != kdfVersionArgon2id {
+		return kdfParams{}, errUnknownKDFVersion
+	}
+
+	p := kdfParams{
+		version: envelope[0],
+		time:    binary.BigEndian.Uint32(envelope[1:5]),
+		memory:  binary.BigEndian.Uint32(envelope[5:9]),
+		threads: envelope[9],
+		salt:    envelope[10:kdfHeaderSize],
+	}
+	// Ограничиваем параметры, чтобы испорченная запись не заняла всю память сервера
+	if p.time == 0 || p.time > maxKDFTime || p.memory == 0 || p.memory > maxKDFMemory ||
+		p.threads == 0 || p.threads > maxKDFThreads {
+		return kdfParams{}, errInvalidKDFParams
+	}
+	return p, nil
+}
+
+// isLegacySecretKey reports whether envelope was produced by SHA-256 based derivation.
+func isLegacySecretKey(envelope []byte) bool {
+	return len(envelope) == legacySecretKeySize
+}
+
+// needsKDFUpgrade reports whether envelope should be re-encrypted with currentKDFParams.
+func needsKDFUpgrade(envelope []byte) bool {
+	if isLegacySecretKey(envelope) {
+		return true
+	}
+	p, err := unmarshalKDFParams(envelope)
+	if err != nil {
+		return false
+	}
+	return p.version != currentKDFParams.version || p.time != currentKDFParams.time ||
+		p.memory != currentKDFParams.memory || p.threads != currentKDFParams.threads
+}
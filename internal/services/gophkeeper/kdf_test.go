@@ -0,0 +1,89 @@
+package gophkeeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFParams keep tests fast, currentKDFParams need 64 MiB and several passes per derivation.
+var testKDFParams = kdfParams{
+	version: kdfVersionArgon2id,
+	time:    1,
+	memory:  64,
+	threads: 1,
+}
+
+func withKDFParams(t *testing.T, params kdfParams) {
+	t.Helper()
+
+	old := currentKDFParams
+	currentKDFParams = params
+	t.Cleanup(func() { currentKDFParams = old })
+}
+
+func TestUnmarshalKDFParams_Bounds(t *testing.T) {
+	valid := kdfParams{
+		version: kdfVersionArgon2id,
+		time:    maxKDFTime,
+		memory:  maxKDFMemory,
+		threads: maxKDFThreads,
+		salt:    make([]byte, kdfSaltSize),
+	}
+	p, err := unmarshalKDFParams(valid.marshal())
+	require.NoError(t, err)
+	assert.Equal(t, valid, p)
+
+	tests := []struct {
+		name   string
+		modify func(p *kdfParams)
+	}{
+		{name: "zero time", modify: func(p *kdfParams) { p.time = 0 }},
+		{name: "time too high", modify: func(p *kdfParams) { p.time = maxKDFTime + 1 }},
+		{name: "zero memory", modify: func(p *kdfParams) { p.memory = 0 }},
+		{name: "memory too high", modify: func(p *kdfParams) { p.memory = maxKDFMemory + 1 }},
+		{name: "zero threads", modify: func(p *kdfParams) { p.threads = 0 }},
+		{name: "threads too high", modify: func(p *kdfParams) { p.threads = maxKDFThreads + 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+			_, err := unmarshalKDFParams(p.marshal())
+			assert.ErrorIs(t, err, errInvalidKDFParams)
+		})
+	}
+}
+
+func TestUnmarshalKDFParams_Malformed(t *testing.T) {
+	header := testKDFParams
+	header.salt = make([]byte, kdfSaltSize)
+	envelope := header.marshal()
+
+	_, err := unmarshalKDFParams(envelope[:kdfHeaderSize-1])
+	assert.ErrorIs(t, err, errCiphertextTooShort)
+
+	envelope[0] = kdfVersionArgon2id + 1
+	_, err = unmarshalKDFParams(envelope)
+	assert.ErrorIs(t, err, errUnknownKDFVersion)
+}
+
+func TestNeedsKDFUpgrade(t *testing.T) {
+	withKDFParams(t, testKDFParams)
+
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+
+	current, err := encryptSecretKey(secretKey, []byte("password"))
+	require.NoError(t, err)
+	assert.False(t, needsKDFUpgrade(current))
+
+	assert.True(t, needsKDFUpgrade(legacyEnvelope(t, secretKey, "password")))
+
+	// Конверт со старыми параметрами обновляется после их изменения
+	stronger := testKDFParams
+	stronger.time++
+	withKDFParams(t, stronger)
+	assert.True(t, needsKDFUpgrade(current))
+}
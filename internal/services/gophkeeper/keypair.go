@@ -0,0 +1,110 @@
+package gophkeeper
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+var (
+	privateKeyAD = []byte("x25519")
+	shareInfo    = []byte("gophkeeper share")
+
+	errInvalidShareKey = errors.New("invalid shared key")
+)
+
+// newKeyPair generates an X25519 keypair and encrypts the private key with the owner secret key.
+func newKeyPair(secretKey []byte) (models.KeyPair, error) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+	sealed, err := seal(secretKey, private.Bytes(), privateKeyAD)
+	if err != nil {
+		return models.KeyPair{}, err
+	}
+	return models.KeyPair{
+		PublicKey:  private.PublicKey().Bytes(),
+		PrivateKey: sealed,
+	}, nil
+}
+
+// openPrivateKey decrypts the private key of the keypair with the owner secret key.
+func openPrivateKey(secretKey []byte, keys models.KeyPair) (*ecdh.PrivateKey, error) {
+	raw, err := open(secretKey, keys.PrivateKey, privateKeyAD)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(raw)
+}
+
+// wrapForRecipient encrypts the record data key so that only the owner of publicKey can open it.
+// A fresh ephemeral key is agreed with the recipient key, the result is the ephemeral public key
+// followed by the data key sealed under the derived key and bound to the record ID.
+func wrapForRecipient(publicKey []byte, dataID int64, dataKey []byte) ([]byte, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublic := ephemeral.PublicKey().Bytes()
+	key, err := shareKey(shared, ephemeralPublic, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(key, dataKey, []byte(strconv.FormatInt(dataID, 10)))
+	if err != nil {
+		return nil, err
+	}
+	return append(ephemeralPublic, sealed...), nil
+}
+
+// unwrapShared opens the data key wrapped by wrapForRecipient with the recipient private key.
+func unwrapShared(private *ecdh.PrivateKey, dataID int64, wrapped []byte) ([]byte, error) {
+	const publicKeySize = 32
+	if len(wrapped) < publicKeySize {
+		return nil, errInvalidShareKey
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(wrapped[:publicKeySize])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := private.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := shareKey(shared, wrapped[:publicKeySize], private.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return open(key, wrapped[publicKeySize:], []byte(strconv.FormatInt(dataID, 10)))
+}
+
+// shareKey derives the key encrypting a shared data key from the X25519 shared secret.
+func shareKey(shared []byte, ephemeralPublic []byte, recipientPublic []byte) ([]byte, error) {
+	info := make([]byte, 0, len(shareInfo)+len(ephemeralPublic)+len(recipientPublic))
+	info = append(append(append(info, shareInfo...), ephemeralPublic...), recipientPublic...)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,106 @@
+package gophkeeper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// IAttemptLimiter counts failed attempts per key and blocks the key for a growing delay.
+// An attempt is reserved by Acquire and ends with Fail or Release.
+type IAttemptLimiter interface {
+	Acquire(key string) time.Duration
+	Fail(key string) time.Duration
+	Release(key string)
+	Reset(key string)
+}
+
+// LoginLimiters throttle failed logins per account and per client IP.
+type LoginLimiters struct {
+	Account IAttemptLimiter
+	IP      IAttemptLimiter
+}
+
+// loginAttempt is an attempt reserved for the account and the client IP.
+// It ends with loginFailed or endLogin.
+type loginAttempt struct {
+	email  string
+	ip     string
+	failed bool
+}
+
+// beginLogin reserves an attempt before the credentials are checked, so parallel guesses
+// can not all pass before the first failure is counted. Returns RetryError if the client IP or the account is blocked.
+// endLogin must be deferred right after the attempt is reserved.
+func (s *Service) beginLogin(email string, ip string) (*loginAttempt, error) {
+	if ip != "" {
+		if left := s.limits.IP.Acquire(ip); left > 0 {
+			return nil, &customerr.RetryError{Err: customerr.ErrTooManyAttempts, RetryAfter: left}
+		}
+	}
+	if left := s.limits.Account.Acquire(accountKey(email)); left > 0 {
+		if ip != "" {
+			s.limits.IP.Release(ip)
+		}
+		return nil, &customerr.RetryError{Err: customerr.ErrAccountLocked, RetryAfter: left}
+	}
+	return &loginAttempt{email: email, ip: ip}, nil
+}
+
+// endLogin releases the attempt unless it failed.
+func (s *Service) endLogin(attempt *loginAttempt) {
+	if attempt.failed {
+		return
+	}
+	s.limits.Account.Release(accountKey(attempt.email))
+	if attempt.ip != "" {
+		s.limits.IP.Release(attempt.ip)
+	}
+}
+
+// loginFailed records the attempt as failed and writes an audit entry when it blocks the account or the IP.
+// userID is zero when the email is unknown, it is counted the same way to not disclose existing accounts.
+func (s *Service) loginFailed(ctx context.Context, log *slog.Logger, attempt *loginAttempt, userID int64) {
+	attempt.failed = true
+	email, ip := attempt.email, attempt.ip
+
+	if delay := s.limits.Account.Fail(accountKey(email)); delay > 0 {
+		log.Warn("account locked", slog.Duration("for", delay))
+		s.audit(ctx, log, models.AuditEvent{
+			UserID:  userID,
+			Event:   models.AuditAccountLocked,
+			IP:      ip,
+			Details: fmt.Sprintf("email=%s locked_for=%s", email, delay),
+		})
+	}
+
+	if ip == "" {
+		return
+	}
+	if delay := s.limits.IP.Fail(ip); delay > 0 {
+		log.Warn("ip blocked", slog.String("ip", ip), slog.Duration("for", delay))
+		s.audit(ctx, log, models.AuditEvent{
+			UserID:  userID,
+			Event:   models.AuditIPBlocked,
+			IP:      ip,
+			Details: fmt.Sprintf("email=%s blocked_for=%s", email, delay),
+		})
+	}
+}
+
+// audit stores the event, failures are only logged to not break the operation being audited.
+func (s *Service) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	if err := s.storage.AddAuditEvent(ctx, event); err != nil {
+		log.Error("failed to write audit event", slog.String("event", event.Event), logger.Err(err))
+	}
+}
+
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,126 @@
+package gophkeeper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+const (
+	recoveryCodeSize  = 20
+	recoveryGroupSize = 4
+)
+
+// RecoverAccount sets a new password using the recovery code instead of the old password.
+// The secret key, and so all user data, is kept. All user sessions are terminated, the used code
+// is replaced with a new one, which is returned. Attempts are throttled the same way as Login.
+func (s *Service) RecoverAccount(ctx context.Context, email string, recoveryCode string, newPassword string) (string, error) {
+	const op = "service.Auth.RecoverAccount"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.String("email", email))
+
+	ip := core.GetContextClientIP(ctx)
+	attempt, err := s.beginLogin(email, ip)
+	if err != nil {
+		log.Info("recovery blocked", slog.String("ip", ip), logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+	defer s.endLogin(attempt)
+
+	user, err := s.storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, customerr.ErrUserNotFound) {
+			log.Warn("user not found", logger.Err(err))
+			s.loginFailed(ctx, log, attempt, 0)
+			return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+		}
+		log.Error("failed to get user", logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	if len(user.RecoveryKey) == 0 {
+		log.Info("recovery is not enabled")
+		s.loginFailed(ctx, log, attempt, user.ID)
+		return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+	}
+
+	secretKey, err := decryptSecretKey(user.RecoveryKey, []byte(normalizeRecoveryCode(recoveryCode)))
+	if err != nil || !compareHashes([]byte(user.SecretKeyHash), []byte(hashSecretKey(secretKey))) {
+		log.Info("invalid recovery code")
+		s.loginFailed(ctx, log, attempt, user.ID)
+		return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+	}
+
+	s.limits.Account.Reset(accountKey(email))
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	encryptedKey, err := encryptSecretKey(secretKey, []byte(newPassword))
+	if err != nil {
+		log.Error("failed to encrypt secret key", logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	newCode, recoveryKey, err := newRecoveryKey(secretKey)
+	if err != nil {
+		log.Error("failed to generate recovery code", logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = s.storage.ResetPassword(ctx, user.ID, passHash, encryptedKey, recoveryKey); err != nil {
+		log.Error("failed to reset password", logger.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+	s.keyring.DeleteUser(user.ID)
+
+	log.Info("account recovered")
+	return newCode, nil
+}
+
+// newRecoveryKey generates a recovery code and wraps secret key with it the same way as with a password.
+func newRecoveryKey(secretKey []byte) (string, []byte, error) {
+	raw := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	recoveryKey, err := encryptSecretKey(secretKey, []byte(code))
+	if err != nil {
+		return "", nil, err
+	}
+	return formatRecoveryCode(code), recoveryKey, nil
+}
+
+// formatRecoveryCode splits code into dash separated groups, e.g. ABCD-EFGH-...
+func formatRecoveryCode(code string) string {
+	groups := make([]string, 0, len(code)/recoveryGroupSize+1)
+	for len(code) > recoveryGroupSize {
+		groups = append(groups, code[:recoveryGroupSize])
+		code = code[recoveryGroupSize:]
+	}
+	groups = append(groups, code)
+	return strings.Join(groups, "-")
+}
+
+// normalizeRecoveryCode reverts formatRecoveryCode and tolerates case and spacing typos.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
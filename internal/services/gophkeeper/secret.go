@@ -0,0 +1,251 @@
+package gophkeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+// encodeSecret serializes the payload of secret matching its kind.
+func encodeSecret(secret models.Secret) ([]byte, error) {
+	var payload any
+	switch secret.Kind {
+	case models.SecretCredentials:
+		if secret.Credentials != nil {
+			payload = secret.Credentials
+		}
+	case models.SecretText:
+		if secret.Text != nil {
+			payload = secret.Text
+		}
+	case models.SecretCard:
+		if secret.Card != nil {
+			payload = secret.Card
+		}
+	case models.SecretBinary:
+		if secret.Binary != nil {
+			payload = secret.Binary
+		}
+	case models.SecretFile:
+		if secret.File != nil {
+			payload = secret.File
+		}
+	default:
+		return nil, customerr.ErrUnknownSecretKind
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("empty %s payload", secret.Kind)
+	}
+	return json.Marshal(payload)
+}
+
+// decodeSecret restores secret of the given kind from its serialized payload.
+func decodeSecret(kind models.SecretKind, payload []byte) (models.Secret, error) {
+	secret := models.Secret{Kind: kind}
+
+	var target any
+	switch kind {
+	case models.SecretCredentials:
+		secret.Credentials = new(models.Credentials)
+		target = secret.Credentials
+	case models.SecretText:
+		secret.Text = new(models.TextNote)
+		target = secret.Text
+	case models.SecretCard:
+		secret.Card = new(models.BankCard)
+		target = secret.Card
+	case models.SecretBinary:
+		secret.Binary = new(models.BinaryData)
+		target = secret.Binary
+	case models.SecretFile:
+		secret.File = new(models.FileRef)
+		target = secret.File
+	default:
+		return models.Secret{}, customerr.ErrUnknownSecretKind
+	}
+
+	if err := json.Unmarshal(payload, target); err != nil {
+		return models.Secret{}, err
+	}
+	return secret, nil
+}
+
+// Wrapped data key of a record:
+//
+//	version (1) | nonce | ciphertext
+//
+// The owner ID and the record ID are authenticated as additional data, so a wrapped key can't be
+// moved to another record. Legacy wrapped keys have no version: nonce | ciphertext without additional data,
+// they are re-wrapped on the next update of the record.
+const (
+	dataKeyVersionBound byte = 1
+
+	// nonce (12) + key (32) + tag (16)
+	legacyWrappedKeySize = 12 + keySize + 16
+)
+
+var errUnknownDataKeyVersion = errors.New("unknown data key version")
+
+// dataKeyAD binds the wrapped data key to its owner and record.
+func dataKeyAD(userID int64, dataID int64) []byte {
+	return []byte(strconv.FormatInt(userID, 10) + ":" + strconv.FormatInt(dataID, 10))
+}
+
+// wrapDataKey encrypts the data key of the record with the owner secret key.
+func wrapDataKey(secretKey []byte, userID int64, dataID int64, dataKey []byte) ([]byte, error) {
+	sealed, err := seal(secretKey, dataKey, dataKeyAD(userID, dataID))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{dataKeyVersionBound}, sealed...), nil
+}
+
+// unwrapDataKey opens the data key of the stored record with the owner secret key.
+func unwrapDataKey(secretKey []byte, userID int64, data models.Data) ([]byte, error) {
+	if isLegacyWrappedKey(data.WrappedKey) {
+		return open(secretKey, data.WrappedKey, nil)
+	}
+	if len(data.WrappedKey) == 0 || data.WrappedKey[0] != dataKeyVersionBound {
+		return nil, errUnknownDataKeyVersion
+	}
+	return open(secretKey, data.WrappedKey[1:], dataKeyAD(userID, data.ID))
+}
+
+// isLegacyWrappedKey reports whether the data key was wrapped without binding to its record.
+func isLegacyWrappedKey(wrappedKey []byte) bool {
+	return len(wrappedKey) == legacyWrappedKeySize
+}
+
+// newRecordKey generates a data key for a new record with the reserved ID.
+func newRecordKey(secretKey []byte, userID int64, dataID int64) (dataKey []byte, wrappedKey []byte, err error) {
+	dataKey, err = generateSecretKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err = wrapDataKey(secretKey, userID, dataID, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, wrappedKey, nil
+}
+
+// newDataKey generates a data key of an upload or a folder and wraps it with the owner secret key.
+func newDataKey(secretKey []byte) (dataKey []byte, wrappedKey []byte, err error) {
+	dataKey, err = generateSecretKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err = seal(secretKey, dataKey, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, wrappedKey, nil
+}
+
+// recordDataKey unwraps data key of the stored record or generates a new one for legacy records.
+// A legacy wrapped key is re-wrapped bound to the record, the data key itself stays the same,
+// so previous versions of the record still open.
+func recordDataKey(secretKey []byte, userID int64, data models.Data) ([]byte, []byte, error) {
+	if len(data.WrappedKey) == 0 {
+		return newRecordKey(secretKey, userID, data.ID)
+	}
+	dataKey, err := unwrapDataKey(secretKey, userID, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isLegacyWrappedKey(data.WrappedKey) {
+		return dataKey, data.WrappedKey, nil
+	}
+	wrappedKey, err := wrapDataKey(secretKey, userID, data.ID, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataKey, wrappedKey, nil
+}
+
+// sealData encodes secret and encrypts it along with metadata values under the record data key.
+// Tags and metadata keys stay in plaintext to allow filtering records by them.
+func sealData(dataKey []byte, wrappedKey []byte, secret models.Secret, attrs models.Attributes) (models.Data, error) {
+	plaintext, err := encodeSecret(secret)
+	if err != nil {
+		return models.Data{}, err
+	}
+
+	payload, err := seal(dataKey, plaintext, []byte(secret.Kind))
+	if err != nil {
+		return models.Data{}, err
+	}
+
+	sealed := models.Attributes{Tags: attrs.Tags}
+	if len(attrs.Metadata) > 0 {
+		sealed.Metadata = make(map[string]string, len(attrs.Metadata))
+		for k, v := range attrs.Metadata {
+			if sealed.Metadata[k], err = sealString(dataKey, v, []byte(k)); err != nil {
+				return models.Data{}, err
+			}
+		}
+	}
+
+	return models.Data{
+		Kind:       secret.Kind,
+		Payload:    payload,
+		WrappedKey: wrappedKey,
+		Attributes: sealed,
+	}, nil
+}
+
+// openData decrypts a stored record with the owner secret key.
+// Legacy records without a data key are returned as is.
+func openData(secretKey []byte, userID int64, data models.Data) (models.Record, error) {
+	if len(data.WrappedKey) == 0 {
+		return dataToRecord(data, data.Payload, data.Attributes)
+	}
+
+	dataKey, err := unwrapDataKey(secretKey, userID, data)
+	if err != nil {
+		return models.Record{}, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return openDataWithKey(dataKey, data)
+}
+
+// openDataWithKey decrypts a stored record with its unwrapped data key.
+func openDataWithKey(dataKey []byte, data models.Data) (models.Record, error) {
+	plaintext, err := open(dataKey, data.Payload, []byte(data.Kind))
+	if err != nil {
+		return models.Record{}, fmt.Errorf("decrypt payload: %w", err)
+	}
+
+	attrs := models.Attributes{Tags: data.Tags}
+	if len(data.Metadata) > 0 {
+		attrs.Metadata = make(map[string]string, len(data.Metadata))
+		for k, v := range data.Metadata {
+			if attrs.Metadata[k], err = openString(dataKey, v, []byte(k)); err != nil {
+				return models.Record{}, fmt.Errorf("decrypt metadata: %w", err)
+			}
+		}
+	}
+
+	return dataToRecord(data, plaintext, attrs)
+}
+
+func dataToRecord(data models.Data, plaintext []byte, attrs models.Attributes) (models.Record, error) {
+	secret, err := decodeSecret(data.Kind, plaintext)
+	if err != nil {
+		return models.Record{}, err
+	}
+	return models.Record{
+		ID:         data.ID,
+		Secret:     secret,
+		Attributes: attrs,
+		CreatedAt:  data.CreatedAt,
+		UpdatedAt:  data.UpdatedAt,
+		Revision:   data.Revision,
+		Version:    data.Version,
+		FolderID:   data.FolderID,
+		DeletedAt:  data.DeletedAt,
+	}, nil
+}
@@ -0,0 +1,91 @@
+package gophkeeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+)
+
+func TestSaveData_StoresCiphertext(t *testing.T) {
+	storage := newFakeStorage()
+	s, keys := newTestService(storage)
+
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	ctx := sessionContext(t, keys, 1, secretKey)
+
+	secret := models.Secret{
+		Kind:        models.SecretCredentials,
+		Credentials: &models.Credentials{Login: "gopher", Password: "hunter2"},
+	}
+	attrs := models.Attributes{
+		Metadata: map[string]string{"site": "example.com"},
+		Tags:     []string{"work"},
+	}
+	ids, err := s.SaveData(ctx, []models.Secret{secret}, attrs)
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+
+	stored := storage.data[ids[0]]
+	assert.NotContains(t, string(stored.Payload), "gopher")
+	assert.NotContains(t, string(stored.Payload), "hunter2")
+	require.Contains(t, stored.Metadata, "site", "metadata keys stay in plaintext for filtering")
+	assert.NotContains(t, stored.Metadata["site"], "example.com")
+	assert.Equal(t, []string{"work"}, stored.Tags)
+	assert.Equal(t, dataKeyVersionBound, stored.WrappedKey[0])
+
+	record, err := s.GetData(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, secret, record.Secret)
+	assert.Equal(t, attrs.Metadata, record.Metadata)
+}
+
+func TestWrappedDataKey_BoundToRecord(t *testing.T) {
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	dataKey, wrappedKey, err := newRecordKey(secretKey, 1, 10)
+	require.NoError(t, err)
+
+	secret := models.Secret{Kind: models.SecretText, Text: &models.TextNote{Text: "note"}}
+	data, err := sealData(dataKey, wrappedKey, secret, models.Attributes{})
+	require.NoError(t, err)
+	data.ID = 10
+
+	record, err := openData(secretKey, 1, data)
+	require.NoError(t, err)
+	assert.Equal(t, secret, record.Secret)
+
+	// Запись, скопированная под другой ID или другого пользователя, не открывается
+	moved := data
+	moved.ID = 11
+	_, err = openData(secretKey, 1, moved)
+	assert.Error(t, err)
+	_, err = openData(secretKey, 2, data)
+	assert.Error(t, err)
+}
+
+func TestRecordDataKey_RewrapsLegacyKey(t *testing.T) {
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	dataKey, legacyKey, err := newDataKey(secretKey)
+	require.NoError(t, err)
+	require.True(t, isLegacyWrappedKey(legacyKey))
+
+	data := models.Data{ID: 7, WrappedKey: legacyKey}
+	unwrapped, err := unwrapDataKey(secretKey, 1, data)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	// Ключ данных не меняется, чтобы старые версии записи открывались
+	key, wrappedKey, err := recordDataKey(secretKey, 1, data)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, key)
+	assert.False(t, isLegacyWrappedKey(wrappedKey))
+
+	unwrapped, err = unwrapDataKey(secretKey, 1, models.Data{ID: 7, WrappedKey: wrappedKey})
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+}
@@ -2,11 +2,6 @@ package gophkeeper
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"crypto/sha256"
-	"crypto/subtle"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -17,33 +12,157 @@ import (
 	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
 	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
 	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/mailer"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
 )
 
 type IStorage interface {
-	Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte) (int64, error)
+	Register(ctx context.Context, email string, passHash []byte, secretKeyHash []byte, encryptedKey []byte, recoveryKey []byte, keys models.KeyPair) (int64, error)
 	Login(ctx context.Context, email string) (models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
-	SaveData(ctx context.Context, data models.PersonalData, userID int64) error
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte) error
+	ResetPassword(ctx context.Context, userID int64, passHash []byte, encryptedKey []byte, recoveryKey []byte) error
+	UpdateEncryptedKey(ctx context.Context, userID int64, encryptedKey []byte) error
+	SetKeyPair(ctx context.Context, userID int64, keys models.KeyPair) error
+	UpdateTOTP(ctx context.Context, userID int64, secret []byte, enabled bool) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	VerifyEmail(ctx context.Context, userID int64, email string) error
+	DeleteUser(ctx context.Context, userID int64, tombstone models.AuditEvent) error
+	SaveData(ctx context.Context, data models.PersonalData, userID int64) ([]int64, error)
+	NewDataIDs(ctx context.Context, n int) ([]int64, error)
+	GetData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	ListData(ctx context.Context, userID int64, opts models.ListOptions) ([]models.Data, error)
+	UpdateData(ctx context.Context, data models.Data, userID int64) (models.Data, error)
+	DeleteData(ctx context.Context, id int64, userID int64, version int64) error
+	ListChanges(ctx context.Context, userID int64, since int64, limit int) ([]models.Data, []models.Tombstone, error)
+	ListUnencryptedData(ctx context.Context, userID int64) ([]models.Data, error)
+	StoreEncryptedData(ctx context.Context, data []models.Data, userID int64) error
+	CreateSession(ctx context.Context, session models.Session) error
+	GetSession(ctx context.Context, id string) (models.Session, error)
+	GetSessionByRefreshHash(ctx context.Context, refreshHash []byte) (models.Session, error)
+	RotateSession(ctx context.Context, id string, oldHash []byte, newHash []byte, wrappedKey []byte, expiresAt time.Time) error
+	ListSessions(ctx context.Context, userID int64) ([]models.Session, error)
+	TouchSession(ctx context.Context, id string, ip string) error
+	RevokeSession(ctx context.Context, id string, userID int64) error
+	CreateAccessToken(ctx context.Context, token models.AccessToken) (models.AccessToken, error)
+	GetAccessToken(ctx context.Context, id string) (models.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID int64) ([]models.AccessToken, error)
+	TouchAccessToken(ctx context.Context, id string) error
+	RevokeAccessToken(ctx context.Context, id string, userID int64) error
+	AddAuditEvent(ctx context.Context, event models.AuditEvent) error
+	CreateUpload(ctx context.Context, upload models.Upload) error
+	GetUpload(ctx context.Context, id string, userID int64) (models.Upload, error)
+	ListExpiredUploads(ctx context.Context) ([]models.Upload, error)
+	AdvanceUpload(ctx context.Context, id string, received int64, newReceived int64, hashState []byte) error
+	CompleteUpload(ctx context.Context, id string, data models.Data, userID int64) (int64, error)
+	DeleteUpload(ctx context.Context, id string) error
+	ListDataVersions(ctx context.Context, id int64, userID int64) ([]models.Data, error)
+	GetDataVersion(ctx context.Context, id int64, userID int64, version int64) (models.Data, error)
+	PruneDataVersions(ctx context.Context, keep int, before time.Time) (int64, error)
+	ListTrash(ctx context.Context, userID int64) ([]models.Data, error)
+	GetTrashedData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	RestoreData(ctx context.Context, id int64, userID int64) (models.Data, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	ListPurgedBlobs(ctx context.Context) ([]string, error)
+	DeletePurgedBlob(ctx context.Context, blobID string) error
+	CreateFolder(ctx context.Context, folder models.FolderData, userID int64) (models.FolderData, error)
+	GetFolder(ctx context.Context, id int64, userID int64) (models.FolderData, error)
+	ListFolders(ctx context.Context, userID int64, parentID int64) ([]models.FolderData, error)
+	ListFolderData(ctx context.Context, userID int64, folderID int64) ([]models.Data, error)
+	RenameFolder(ctx context.Context, id int64, userID int64, name []byte) (models.FolderData, error)
+	MoveFolder(ctx context.Context, id int64, userID int64, parentID int64) (models.FolderData, error)
+	DeleteFolder(ctx context.Context, id int64, userID int64) error
+	MoveData(ctx context.Context, id int64, userID int64, folderID int64) (models.Data, error)
+	SaveShare(ctx context.Context, share models.Share) (models.Share, error)
+	DeleteShare(ctx context.Context, dataID int64, ownerID int64, recipientEmail string) error
+	ListSharedData(ctx context.Context, recipientID int64) ([]models.SharedData, error)
+	GetSharedData(ctx context.Context, dataID int64, recipientID int64) (models.SharedData, error)
+}
+
+// IBlobStore keeps encrypted chunks of file records and uploads.
+type IBlobStore interface {
+	PutChunk(ctx context.Context, blobID string, index int, data []byte) error
+	GetChunk(ctx context.Context, blobID string, index int) ([]byte, error)
+	DeleteBlob(ctx context.Context, blobID string) error
+}
+
+// IKeyring keeps unwrapped secret keys of authenticated sessions.
+type IKeyring interface {
+	Put(sessionID string, userID int64, key []byte, ttl time.Duration)
+	Get(sessionID string, userID int64) ([]byte, bool)
+	Take(sessionID string, userID int64) ([]byte, bool)
+	Delete(sessionID string)
+	DeleteUser(userID int64)
+}
+
+// ITokenIssuer signs access tokens, login challenge tokens and email verification codes.
+type ITokenIssuer interface {
+	NewToken(user *models.User, sessionID string, duration time.Duration) (string, error)
+	NewChallengeToken(userID int64, challengeID string, duration time.Duration) (string, error)
+	ParseChallengeToken(token string) (*core.Claims, error)
+	NewEmailVerificationToken(userID int64, email string, duration time.Duration) (string, error)
+	ParseEmailVerificationToken(token string) (*core.Claims, error)
+}
+
+// IChangeNotifier tells watching clients that records of the user changed.
+type IChangeNotifier interface {
+	Publish(userID int64)
+	Subscribe(userID int64) (<-chan struct{}, func())
+}
+
+// IMailer delivers emails to users.
+type IMailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
 }
 
 type Service struct {
 	logger *slog.Logger
 
-	storage  IStorage
-	tokenTTL time.Duration
+	storage    IStorage
+	blobs      IBlobStore
+	keyring    IKeyring
+	tokens     ITokenIssuer
+	mailer     IMailer
+	changes    IChangeNotifier
+	limits     LoginLimiters
+	tokenTTL   time.Duration
+	refreshTTL time.Duration
+	// verifyTTL is lifetime of email verification codes
+	verifyTTL time.Duration
 }
 
 // New returns a new instance of the Auth service
-func New(logger *slog.Logger, storage IStorage, tokenTTL time.Duration) *Service {
+func New(
+	logger *slog.Logger,
+	storage IStorage,
+	blobs IBlobStore,
+	keyring IKeyring,
+	tokens ITokenIssuer,
+	mailer IMailer,
+	changes IChangeNotifier,
+	limits LoginLimiters,
+	tokenTTL time.Duration,
+	refreshTTL time.Duration,
+	verifyTTL time.Duration) *Service {
 	return &Service{
-		storage:  storage,
-		logger:   logger,
-		tokenTTL: tokenTTL,
+		storage:    storage,
+		blobs:      blobs,
+		keyring:    keyring,
+		tokens:     tokens,
+		mailer:     mailer,
+		changes:    changes,
+		limits:     limits,
+		logger:     logger,
+		tokenTTL:   tokenTTL,
+		refreshTTL: refreshTTL,
+		verifyTTL:  verifyTTL,
 	}
 }
 
 // Register creates new user in the system, if email is not exist already. Returns errors, if exists, userID if not.
-func (s *Service) Register(ctx context.Context, email string, password string) (int64, error) {
+// With withRecovery a recovery code is generated and returned, it must be shown to the user only once.
+func (s *Service) Register(ctx context.Context, email string, password string, withRecovery bool) (int64, string, error) {
 	const op = "service.Auth.Register"
 
 	log := s.logger.With(
@@ -55,8 +174,8 @@ func (s *Service) Register(ctx context.Context, email string, password string) (
 	// Генерация секретного ключа
 	secretKey, err := generateSecretKey()
 	if err != nil {
-		log.Error("failed to generate secret key", err.Error())
-		return 0, fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to generate secret key", logger.Err(err))
+		return 0, "", fmt.Errorf("%s:%w", op, err)
 	}
 
 	// Хеширование секретного ключа
@@ -65,33 +184,55 @@ func (s *Service) Register(ctx context.Context, email string, password string) (
 	// Хеширование пароля
 	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Error("failed to generate password hash", err.Error())
-		return 0, fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to generate password hash", logger.Err(err))
+		return 0, "", fmt.Errorf("%s:%w", op, err)
 	}
 
 	// Шифрование секретного ключа на основе пароля
 	encryptedKey, err := encryptSecretKey(secretKey, []byte(password))
 	if err != nil {
-		log.Error("failed to encrypt secret key", err.Error())
-		return 0, fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to encrypt secret key", logger.Err(err))
+		return 0, "", fmt.Errorf("%s:%w", op, err)
 	}
 
-	userID, err := s.storage.Register(ctx, email, passHash, []byte(secretKeyHash), encryptedKey)
+	var recoveryCode string
+	var recoveryKey []byte
+	if withRecovery {
+		recoveryCode, recoveryKey, err = newRecoveryKey(secretKey)
+		if err != nil {
+			log.Error("failed to generate recovery code", logger.Err(err))
+			return 0, "", fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	// Ключи для обмена записями с другими пользователями
+	keys, err := newKeyPair(secretKey)
+	if err != nil {
+		log.Error("failed to generate keypair", logger.Err(err))
+		return 0, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	userID, err := s.storage.Register(ctx, email, passHash, []byte(secretKeyHash), encryptedKey, recoveryKey, keys)
 	if err != nil {
 		if errors.Is(err, customerr.ErrUserExists) {
-			log.Warn("user already exists", err.Error())
-			return 0, fmt.Errorf("%s:%w", op, customerr.ErrUserExists)
+			log.Warn("user already exists", logger.Err(err))
+			return 0, "", fmt.Errorf("%s:%w", op, customerr.ErrUserExists)
 		}
-		log.Error("failed to register user", err.Error())
-		return 0, fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to register user", logger.Err(err))
+		return 0, "", fmt.Errorf("%s:%w", op, err)
 	}
 
 	log.Info("user registered")
-	return userID, nil
+
+	// Письмо не блокирует регистрацию, пользователь может запросить его повторно
+	if err = s.sendVerificationEmail(ctx, userID, email); err != nil {
+		log.Error("failed to send verification email", logger.Err(err))
+	}
+	return userID, recoveryCode, nil
 }
 
-// Login checks if provided credentials exists in the system and returns token, if yes. Error, if not.
-func (s *Service) Login(ctx context.Context, email string, password string) (string, error) {
+// Login checks if provided credentials exists in the system and returns tokens of a new session, if yes. Error, if not.
+func (s *Service) Login(ctx context.Context, email string, password string, device models.Device) (models.Tokens, error) {
 	const op = "service.Auth.Login"
 
 	log := s.logger.With(
@@ -100,132 +241,214 @@ func (s *Service) Login(ctx context.Context, email string, password string) (str
 
 	log.Info("login")
 
+	ip := core.GetContextClientIP(ctx)
+	attempt, err := s.beginLogin(email, ip)
+	if err != nil {
+		log.Info("login blocked", slog.String("ip", ip), logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer s.endLogin(attempt)
+
 	user, err := s.storage.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, customerr.ErrUserNotFound) {
-			s.logger.Warn("user not found", err.Error())
-			return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+			s.logger.Warn("user not found", logger.Err(err))
+			s.loginFailed(ctx, log, attempt, 0)
+			return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
 		}
 
-		log.Error("failed to get user", err.Error())
-		return "", fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to get user", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
 	}
 
-	decryptedKey, err := decryptSecretKey(user.EncryptedKey, []byte(password))
+	decryptedKey, err := unlockSecretKey(user, password)
 	if err != nil {
-		log.Error("failed to decrypt secret key", err.Error())
-		return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+		log.Info("invalid credentials", logger.Err(err))
+		s.loginFailed(ctx, log, attempt, user.ID)
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
 	}
 
-	if !compareHashes([]byte(user.SecretKeyHash), []byte(hashSecretKey(decryptedKey))) {
-		log.Info("invalid credentials")
-		return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+	log.Info("user logged in successfuly")
+
+	if needsKDFUpgrade(user.EncryptedKey) {
+		if err = s.upgradeEncryptedKey(ctx, user.ID, decryptedKey, password); err != nil {
+			// Старый ключ остаётся рабочим, обновим его при следующем входе
+			log.Error("failed to upgrade secret key encryption", logger.Err(err))
+		}
 	}
 
-	// Проверка пароля
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		log.Info("invalid credentials", err.Error())
-		return "", fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
+	if err = s.encryptLegacyData(ctx, user.ID, decryptedKey); err != nil {
+		// Не блокируем вход, попробуем снова при следующем входе
+		log.Error("failed to encrypt legacy data", logger.Err(err))
 	}
 
-	log.Info("user logged in successfuly")
+	if len(user.Keys.PublicKey) == 0 {
+		if err = s.createKeyPair(ctx, user.ID, decryptedKey); err != nil {
+			// Без ключей пользователь только не может получать записи, создадим их при следующем входе
+			log.Error("failed to create keypair", logger.Err(err))
+		}
+	}
 
-	// Генерация токена
-	token, err := core.NewToken(ctx, user, s.tokenTTL)
+	if user.TOTPEnabled {
+		// Счётчик сбрасывается только после второго фактора
+		challengeToken, err := s.newChallenge(user.ID, decryptedKey)
+		if err != nil {
+			log.Error("failed to create challenge", logger.Err(err))
+			return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+		}
+		log.Info("second factor required")
+		return models.Tokens{ChallengeToken: challengeToken}, nil
+	}
+
+	s.limits.Account.Reset(accountKey(email))
+
+	tokens, err := s.newSession(ctx, user, decryptedKey, device)
 	if err != nil {
-		log.Error("failed to generate token", err.Error())
-		return "", fmt.Errorf("%s:%w", op, err)
+		log.Error("failed to create session", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (s *Service) SaveData(ctx context.Context, data models.PersonalData) error {
-	const op = "service.Keeper.Register"
+// ChangePassword re-encrypts the secret key of the current user under the new password,
+// terminates all user sessions and returns tokens of a new session.
+func (s *Service) ChangePassword(ctx context.Context, oldPassword string, newPassword string) (models.Tokens, error) {
+	const op = "service.Auth.ChangePassword"
 
 	log := s.logger.With(
 		slog.String("op", op))
 
 	userID := core.GetContextUserID(ctx)
 	if userID == 0 {
-		return customerr.ErrFailedGetUserID
+		return models.Tokens{}, customerr.ErrFailedGetUserID
 	}
+	log = log.With(slog.Int64("userID", userID))
 
-	err := s.storage.SaveData(ctx, data, userID)
+	user, err := s.storage.GetUserByID(ctx, userID)
 	if err != nil {
-		log.Error("failed to save data", err.Error())
-		return err
+		log.Error("failed to get user", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
 	}
-	return nil
-}
 
-func generateSecretKey() ([]byte, error) {
-	key := make([]byte, 32)
-	if _, err := rand.Read(key); err != nil {
-		return nil, err
+	secretKey, err := unlockSecretKey(user, oldPassword)
+	if err != nil {
+		log.Info("invalid credentials", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidCredentials)
 	}
-	return key, nil
-}
-
-func hashSecretKey(key []byte) string {
-	hash := sha256.Sum256(key)
-	return string(hash[:])
-}
 
-func encryptSecretKey(key []byte, password []byte) ([]byte, error) {
-	// Преобразование пароля в ключ с использованием хеш-функции
-	hashedPassword := sha256.Sum256(password)
-
-	block, err := aes.NewCipher(hashedPassword[:])
+	passHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, err
+		log.Error("failed to generate password hash", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
 	}
-	gcm, err := cipher.NewGCM(block)
+
+	encryptedKey, err := encryptSecretKey(secretKey, []byte(newPassword))
 	if err != nil {
-		return nil, err
+		log.Error("failed to encrypt secret key", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return nil, err
+	// Новая сессия остаётся на том же устройстве
+	var device models.Device
+	if current, err := s.storage.GetSession(ctx, core.GetContextSessionID(ctx)); err == nil {
+		device = current.Device
 	}
 
-	ciphertext := gcm.Seal(nil, nonce, key, nil)
-	ciphertext = append(nonce, ciphertext...)
+	// Сессии отзываются в той же транзакции, ключи из памяти удаляем только после её фиксации
+	if err = s.storage.UpdatePassword(ctx, userID, passHash, encryptedKey); err != nil {
+		log.Error("failed to update password", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.keyring.DeleteUser(userID)
 
-	return ciphertext, nil
-}
+	tokens, err := s.newSession(ctx, user, secretKey, device)
+	if err != nil {
+		log.Error("failed to create session", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
 
-// decryptSecretKey расшифровывает секретный ключ на основе пароля.
-func decryptSecretKey(ciphertext []byte, password []byte) ([]byte, error) {
-	// Преобразование пароля в ключ с использованием хеш-функции
-	hashedPassword := sha256.Sum256(password)
+	log.Info("password changed")
+	return tokens, nil
+}
 
-	block, err := aes.NewCipher(hashedPassword[:])
+// unlockSecretKey decrypts the user secret key with password and checks password hash.
+func unlockSecretKey(user *models.User, password string) ([]byte, error) {
+	secretKey, err := decryptSecretKey(user.EncryptedKey, []byte(password))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to decrypt secret key: %w", err)
 	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
+
+	if !compareHashes([]byte(user.SecretKeyHash), []byte(hashSecretKey(secretKey))) {
+		return nil, customerr.ErrInvalidCredentials
+	}
+
+	// Проверка пароля
+	if err = bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
 		return nil, err
 	}
+	return secretKey, nil
+}
 
-	if len(ciphertext) < gcm.NonceSize() {
-		return nil, fmt.Errorf("ciphertext too short: %w", err)
+// upgradeEncryptedKey re-encrypts the secret key with the current KDF parameters.
+func (s *Service) upgradeEncryptedKey(ctx context.Context, userID int64, secretKey []byte, password string) error {
+	encryptedKey, err := encryptSecretKey(secretKey, []byte(password))
+	if err != nil {
+		return err
 	}
+	return s.storage.UpdateEncryptedKey(ctx, userID, encryptedKey)
+}
 
-	nonce := ciphertext[:gcm.NonceSize()]
-	ciphertext = ciphertext[gcm.NonceSize():]
+// createKeyPair generates the sharing keypair of a user registered before sharing was added.
+func (s *Service) createKeyPair(ctx context.Context, userID int64, secretKey []byte) error {
+	keys, err := newKeyPair(secretKey)
+	if err != nil {
+		return err
+	}
+	return s.storage.SetKeyPair(ctx, userID, keys)
+}
 
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+// encryptLegacyData encrypts records stored before encryption at rest was introduced.
+func (s *Service) encryptLegacyData(ctx context.Context, userID int64, secretKey []byte) error {
+	legacy, err := s.storage.ListUnencryptedData(ctx, userID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
 	}
 
-	return plaintext, nil
+	encrypted := make([]models.Data, 0, len(legacy))
+	for _, v := range legacy {
+		secret, err := decodeSecret(v.Kind, v.Payload)
+		if err != nil {
+			return fmt.Errorf("decode record %d: %w", v.ID, err)
+		}
+		dataKey, wrappedKey, err := newRecordKey(secretKey, userID, v.ID)
+		if err != nil {
+			return err
+		}
+		data, err := sealData(dataKey, wrappedKey, secret, v.Attributes)
+		if err != nil {
+			return err
+		}
+		data.ID = v.ID
+		encrypted = append(encrypted, data)
+	}
+
+	return s.storage.StoreEncryptedData(ctx, encrypted, userID)
 }
 
-// compareHashes сравнивает два хеша без раскрывания конкретного значения.
-func compareHashes(hash1, hash2 []byte) bool {
-	return subtle.ConstantTimeCompare(hash1, hash2) == 1
+// sessionKey returns the current user ID and the secret key unlocked for the current session.
+func (s *Service) sessionKey(ctx context.Context) (int64, []byte, error) {
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return 0, nil, customerr.ErrFailedGetUserID
+	}
+
+	key, ok := s.keyring.Get(core.GetContextSessionID(ctx), userID)
+	if !ok {
+		return 0, nil, customerr.ErrSessionKeyNotFound
+	}
+	return userID, key, nil
 }
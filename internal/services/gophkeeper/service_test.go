@@ -0,0 +1,152 @@
+package gophkeeper
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/attempts"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/broker"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/keyring"
+)
+
+const testSessionID = "session"
+
+// fakeStorage keeps a single user and records in memory. Methods the tests don't need are left
+// to the embedded nil IStorage and panic when called.
+type fakeStorage struct {
+	IStorage
+
+	user   *models.User
+	lastID int64
+	data   map[int64]models.Data
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{data: make(map[int64]models.Data)}
+}
+
+func (f *fakeStorage) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	if f.user == nil || f.user.Email != email {
+		return nil, customerr.ErrUserNotFound
+	}
+	user := *f.user
+	return &user, nil
+}
+
+func (f *fakeStorage) UpdateEncryptedKey(_ context.Context, _ int64, encryptedKey []byte) error {
+	f.user.EncryptedKey = encryptedKey
+	return nil
+}
+
+func (f *fakeStorage) ListUnencryptedData(context.Context, int64) ([]models.Data, error) {
+	return nil, nil
+}
+
+func (f *fakeStorage) CreateSession(context.Context, models.Session) error {
+	return nil
+}
+
+func (f *fakeStorage) NewDataIDs(_ context.Context, n int) ([]int64, error) {
+	ids := make([]int64, n)
+	for i := range ids {
+		f.lastID++
+		ids[i] = f.lastID
+	}
+	return ids, nil
+}
+
+func (f *fakeStorage) SaveData(_ context.Context, data models.PersonalData, _ int64) ([]int64, error) {
+	ids := make([]int64, 0, len(data.PData))
+	for _, v := range data.PData {
+		f.data[v.ID] = v
+		ids = append(ids, v.ID)
+	}
+	return ids, nil
+}
+
+func (f *fakeStorage) GetData(_ context.Context, id int64, _ int64) (models.Data, error) {
+	data, ok := f.data[id]
+	if !ok {
+		return models.Data{}, customerr.ErrDataNotFound
+	}
+	return data, nil
+}
+
+// fakeTokens issues unsigned tokens, only NewToken is used by the tests.
+type fakeTokens struct {
+	ITokenIssuer
+}
+
+func (fakeTokens) NewToken(_ *models.User, sessionID string, _ time.Duration) (string, error) {
+	return "access-" + sessionID, nil
+}
+
+func newTestService(storage IStorage) (*Service, *keyring.Keyring) {
+	keys := keyring.New()
+	limits := LoginLimiters{
+		Account: attempts.New(5, time.Second, time.Minute),
+		IP:      attempts.New(100, time.Second, time.Minute),
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, storage, nil, keys, fakeTokens{}, nil, broker.New(), limits, time.Hour, time.Hour, time.Hour), keys
+}
+
+// sessionContext returns context of a logged in user whose secret key is unlocked.
+func sessionContext(t *testing.T, keys *keyring.Keyring, userID int64, secretKey []byte) context.Context {
+	t.Helper()
+
+	keys.Put(testSessionID, userID, secretKey, time.Hour)
+	return core.WithClaims(context.Background(), &core.Claims{UserID: userID, SessionID: testSessionID})
+}
+
+func TestLogin_UpgradesLegacySecretKey(t *testing.T) {
+	withKDFParams(t, testKDFParams)
+	const password = "correct horse"
+
+	secretKey, err := generateSecretKey()
+	require.NoError(t, err)
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	keys, err := newKeyPair(secretKey)
+	require.NoError(t, err)
+
+	storage := newFakeStorage()
+	storage.user = &models.User{
+		ID:            1,
+		Email:         "gopher@example.com",
+		PassHash:      passHash,
+		SecretKeyHash: hashSecretKey(secretKey),
+		EncryptedKey:  legacyEnvelope(t, secretKey, password),
+		Keys:          keys,
+	}
+	s, _ := newTestService(storage)
+
+	tokens, err := s.Login(context.Background(), storage.user.Email, password, models.Device{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	upgraded := storage.user.EncryptedKey
+	assert.False(t, isLegacySecretKey(upgraded))
+	assert.False(t, needsKDFUpgrade(upgraded))
+	unlocked, err := decryptSecretKey(upgraded, []byte(password))
+	require.NoError(t, err)
+	assert.Equal(t, secretKey, unlocked)
+
+	// После обновления вход работает с новым конвертом и не переписывает его
+	_, err = s.Login(context.Background(), storage.user.Email, password, models.Device{})
+	require.NoError(t, err)
+	assert.Equal(t, upgraded, storage.user.EncryptedKey)
+
+	_, err = s.Login(context.Background(), storage.user.Email, "wrong", models.Device{})
+	assert.ErrorIs(t, err, customerr.ErrInvalidCredentials)
+}
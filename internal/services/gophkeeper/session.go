@@ -0,0 +1,235 @@
+package gophkeeper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// lastSeenPrecision limits how often last use of a session is written.
+const lastSeenPrecision = time.Minute
+
+// RefreshToken exchanges a refresh token for a new token pair of the same session.
+// Every refresh token is accepted only once, presenting a used one revokes the session.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	const op = "service.Auth.RefreshToken"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	refreshHash := hashTokenSecret(refreshToken)
+	session, err := s.storage.GetSessionByRefreshHash(ctx, refreshHash)
+	if err != nil {
+		if errors.Is(err, customerr.ErrSessionNotFound) {
+			return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+		}
+		log.Error("failed to get session", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+	log = log.With(slog.Int64("userID", session.UserID))
+
+	if !bytes.Equal(session.RefreshHash, refreshHash) {
+		// Токен уже был использован: скорее всего он украден, завершаем сессию
+		log.Warn("refresh token reuse detected")
+		s.endSession(ctx, session.ID, session.UserID)
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+
+	if !session.Active(time.Now()) {
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+
+	key, err := tokenSecretKey(refreshToken)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+	secretKey, err := open(key, session.WrappedKey, []byte(session.ID))
+	if err != nil {
+		log.Error("failed to unwrap session key", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+
+	user, err := s.storage.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	newRefresh, wrappedKey, err := wrapSessionKey(session.ID, secretKey)
+	if err != nil {
+		log.Error("failed to issue refresh token", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	err = s.storage.RotateSession(ctx, session.ID, refreshHash, hashTokenSecret(newRefresh), wrappedKey, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		if errors.Is(err, customerr.ErrSessionNotFound) {
+			// Параллельный запрос с тем же токеном успел раньше
+			return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+		}
+		log.Error("failed to rotate refresh token", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	accessToken, err := s.tokens.NewToken(user, session.ID, s.tokenTTL)
+	if err != nil {
+		log.Error("failed to create token", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// После рестарта сервера ключ восстанавливается здесь
+	s.keyring.Put(session.ID, user.ID, secretKey, s.tokenTTL)
+
+	return models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: newRefresh,
+	}, nil
+}
+
+// Logout terminates the current session, its access and refresh tokens stop working immediately.
+func (s *Service) Logout(ctx context.Context) error {
+	const op = "service.Auth.Logout"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+
+	sessionID := core.GetContextSessionID(ctx)
+	if err := s.storage.RevokeSession(ctx, sessionID, userID); err != nil && !errors.Is(err, customerr.ErrSessionNotFound) {
+		s.logger.Error("failed to revoke session", slog.String("op", op), logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	s.keyring.Delete(sessionID)
+	return nil
+}
+
+// ValidateSession checks that session of the token was not revoked or expired and updates its last use.
+func (s *Service) ValidateSession(ctx context.Context, claims *core.Claims) error {
+	session, err := s.storage.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if session.UserID != claims.UserID || !session.Active(now) {
+		return customerr.ErrSessionNotFound
+	}
+
+	// Не пишем в базу на каждый запрос
+	if now.Sub(session.LastSeenAt) > lastSeenPrecision {
+		if err = s.storage.TouchSession(ctx, session.ID, core.GetContextClientIP(ctx)); err != nil {
+			s.logger.Error("failed to touch session", logger.Err(err))
+		}
+	}
+	return nil
+}
+
+// ListSessions returns active sessions of the current user.
+func (s *Service) ListSessions(ctx context.Context) ([]models.Session, error) {
+	const op = "service.Auth.ListSessions"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return nil, customerr.ErrFailedGetUserID
+	}
+
+	sessions, err := s.storage.ListSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list sessions", slog.String("op", op), logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession terminates a session of the current user, e.g. on a lost device.
+func (s *Service) RevokeSession(ctx context.Context, sessionID string) error {
+	const op = "service.Auth.RevokeSession"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+
+	if err := s.storage.RevokeSession(ctx, sessionID, userID); err != nil {
+		if errors.Is(err, customerr.ErrSessionNotFound) {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		s.logger.Error("failed to revoke session", slog.String("op", op), logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	s.keyring.Delete(sessionID)
+
+	s.logger.Info("session revoked", slog.String("op", op), slog.Int64("userID", userID))
+	return nil
+}
+
+// newSession stores a new session and issues its tokens. Secret key is kept unlocked
+// for the lifetime of the access token and wrapped by the refresh token to unlock it again on refresh.
+func (s *Service) newSession(ctx context.Context, user *models.User, secretKey []byte, device models.Device) (models.Tokens, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	refreshToken, wrappedKey, err := wrapSessionKey(sessionID, secretKey)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	err = s.storage.CreateSession(ctx, models.Session{
+		ID:          sessionID,
+		UserID:      user.ID,
+		RefreshHash: hashTokenSecret(refreshToken),
+		WrappedKey:  wrappedKey,
+		Device:      device,
+		ExpiresAt:   time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	// Генерация токена
+	accessToken, err := s.tokens.NewToken(user, sessionID, s.tokenTTL)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	s.keyring.Put(sessionID, user.ID, secretKey, s.tokenTTL)
+	return models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// endSession revokes a single session, errors are only logged since the caller fails anyway.
+func (s *Service) endSession(ctx context.Context, sessionID string, userID int64) {
+	if err := s.storage.RevokeSession(ctx, sessionID, userID); err != nil && !errors.Is(err, customerr.ErrSessionNotFound) {
+		s.logger.Error("failed to revoke session", logger.Err(err))
+	}
+	s.keyring.Delete(sessionID)
+}
+
+// wrapSessionKey issues a refresh token and wraps secret key by it, bound to the session ID.
+func wrapSessionKey(sessionID string, secretKey []byte) (string, []byte, error) {
+	refreshToken, err := newTokenSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := tokenSecretKey(refreshToken)
+	if err != nil {
+		return "", nil, err
+	}
+	wrappedKey, err := seal(key, secretKey, []byte(sessionID))
+	if err != nil {
+		return "", nil, err
+	}
+	return refreshToken, wrappedKey, nil
+}
@@ -0,0 +1,266 @@
+package gophkeeper
+
+import (
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// ShareRecord gives the recipient access to a record of the current user. The record data key is wrapped
+// for the recipient public key, so the server can not open it without the recipient secret key.
+// Sharing the record again replaces the permission.
+func (s *Service) ShareRecord(ctx context.Context, id int64, recipientEmail string, permission models.SharePermission) (models.Share, error) {
+	const op = "service.Keeper.ShareRecord"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Share{}, err
+	}
+
+	data, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return models.Share{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if data.Kind == models.SecretFile {
+		return models.Share{}, fmt.Errorf("%s:%w", op, customerr.ErrShareFile)
+	}
+
+	recipient, err := s.storage.GetUserByEmail(ctx, recipientEmail)
+	if err != nil {
+		if errors.Is(err, customerr.ErrUserNotFound) {
+			return models.Share{}, fmt.Errorf("%s:%w", op, customerr.ErrRecipientNotFound)
+		}
+		log.Error("failed to get recipient", logger.Err(err))
+		return models.Share{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if recipient.ID == userID {
+		return models.Share{}, fmt.Errorf("%s:%w", op, customerr.ErrShareSelf)
+	}
+	if len(recipient.Keys.PublicKey) == 0 {
+		return models.Share{}, fmt.Errorf("%s:%w", op, customerr.ErrRecipientNoKeys)
+	}
+
+	dataKey, err := unwrapDataKey(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to unwrap data key", logger.Err(err))
+		return models.Share{}, fmt.Errorf("%s:%w", op, err)
+	}
+	wrappedKey, err := wrapForRecipient(recipient.Keys.PublicKey, id, dataKey)
+	if err != nil {
+		log.Error("failed to wrap data key for recipient", logger.Err(err))
+		return models.Share{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	share := models.Share{
+		DataID:         id,
+		OwnerID:        userID,
+		RecipientID:    recipient.ID,
+		RecipientEmail: recipient.Email,
+		WrappedKey:     wrappedKey,
+		Permission:     permission,
+	}
+	if share, err = s.storage.SaveShare(ctx, share); err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to save share", logger.Err(err))
+		}
+		return models.Share{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	s.audit(ctx, log, models.AuditEvent{
+		UserID:  userID,
+		Event:   models.AuditRecordShared,
+		IP:      core.GetContextClientIP(ctx),
+		Details: fmt.Sprintf("record=%d recipient=%d permission=%s", id, recipient.ID, permission),
+	})
+	return share, nil
+}
+
+// RevokeShare takes back access of the recipient to a record of the current user.
+func (s *Service) RevokeShare(ctx context.Context, id int64, recipientEmail string) error {
+	const op = "service.Keeper.RevokeShare"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+
+	if err := s.storage.DeleteShare(ctx, id, userID, recipientEmail); err != nil {
+		if !errors.Is(err, customerr.ErrShareNotFound) {
+			log.Error("failed to delete share", logger.Err(err))
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	s.audit(ctx, log, models.AuditEvent{
+		UserID:  userID,
+		Event:   models.AuditShareRevoked,
+		IP:      core.GetContextClientIP(ctx),
+		Details: fmt.Sprintf("record=%d", id),
+	})
+	return nil
+}
+
+// ListSharedWithMe returns records other users shared with the current user.
+func (s *Service) ListSharedWithMe(ctx context.Context) ([]models.SharedRecord, error) {
+	const op = "service.Keeper.ListSharedWithMe"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := s.storage.ListSharedData(ctx, userID)
+	if err != nil {
+		log.Error("failed to list shared data", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	if len(shared) == 0 {
+		return nil, nil
+	}
+
+	private, err := s.privateKey(ctx, userID, secretKey)
+	if err != nil {
+		log.Error("failed to open private key", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	records := make([]models.SharedRecord, 0, len(shared))
+	for _, v := range shared {
+		record, err := openShared(private, v)
+		if err != nil {
+			log.Error("failed to decrypt shared record", slog.Int64("recordID", v.DataID), logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		records = append(records, models.SharedRecord{Share: v.Share, Record: record})
+	}
+	return records, nil
+}
+
+// UpdateSharedRecord replaces secret and attributes of a record shared with the current user for writing.
+// The record stays with its owner and keeps its data key, so other shares remain valid.
+func (s *Service) UpdateSharedRecord(ctx context.Context, id int64, version int64, secret models.Secret, attrs models.Attributes) (models.Record, error) {
+	const op = "service.Keeper.UpdateSharedRecord"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	shared, err := s.storage.GetSharedData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get shared data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if shared.Permission != models.ShareWrite {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrShareReadOnly)
+	}
+	if secret.Kind == models.SecretFile {
+		return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrFileRecord)
+	}
+
+	private, err := s.privateKey(ctx, userID, secretKey)
+	if err != nil {
+		log.Error("failed to open private key", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	dataKey, err := unwrapShared(private, id, shared.WrappedKey)
+	if err != nil {
+		log.Error("failed to unwrap shared key", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if shared.Data.Version != version {
+		return models.Record{}, fmt.Errorf("%s:%w", op, sharedConflict(dataKey, shared.Data))
+	}
+
+	sealed, err := sealData(dataKey, shared.Data.WrappedKey, secret, attrs)
+	if err != nil {
+		log.Error("failed to encrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	sealed.ID = id
+	sealed.Version = version
+
+	data, err := s.storage.UpdateData(ctx, sealed, shared.OwnerID)
+	if err != nil {
+		if errors.Is(err, customerr.ErrVersionConflict) {
+			if current, getErr := s.storage.GetSharedData(ctx, id, userID); getErr == nil {
+				err = sharedConflict(dataKey, current.Data)
+			}
+			return models.Record{}, fmt.Errorf("%s:%w", op, err)
+		}
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to update data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	// Изменения видят клиенты владельца
+	s.changes.Publish(shared.OwnerID)
+
+	record, err := openDataWithKey(dataKey, data)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	// Папки владельца получателю не видны
+	record.FolderID = 0
+	return record, nil
+}
+
+// privateKey opens the sharing private key of the user.
+func (s *Service) privateKey(ctx context.Context, userID int64, secretKey []byte) (*ecdh.PrivateKey, error) {
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Keys.PrivateKey) == 0 {
+		return nil, customerr.ErrRecipientNoKeys
+	}
+	return openPrivateKey(secretKey, user.Keys)
+}
+
+// openShared decrypts a record shared with the owner of private.
+func openShared(private *ecdh.PrivateKey, shared models.SharedData) (models.Record, error) {
+	dataKey, err := unwrapShared(private, shared.DataID, shared.WrappedKey)
+	if err != nil {
+		return models.Record{}, fmt.Errorf("unwrap shared key: %w", err)
+	}
+	return openDataWithKey(dataKey, shared.Data)
+}
+
+// sharedConflict returns ConflictError with the current shared record,
+// or plain ErrVersionConflict when the record can not be decrypted.
+func sharedConflict(dataKey []byte, current models.Data) error {
+	record, err := openDataWithKey(dataKey, current)
+	if err != nil {
+		return customerr.ErrVersionConflict
+	}
+	return &customerr.ConflictError{Current: record}
+}
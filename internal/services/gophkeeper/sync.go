@@ -0,0 +1,68 @@
+package gophkeeper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// Sync returns up to limit records changed and deleted after revision since, ordered by revision.
+// Changes.Revision is the cursor for the next call, it equals since when nothing changed.
+func (s *Service) Sync(ctx context.Context, since int64, limit int) (models.Changes, error) {
+	const op = "service.Keeper.Sync"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("since", since))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Changes{}, err
+	}
+
+	// Лишний элемент в каждом списке показывает, есть ли следующая страница
+	data, tombstones, err := s.storage.ListChanges(ctx, userID, since, limit+1)
+	if err != nil {
+		log.Error("failed to list changes", logger.Err(err))
+		return models.Changes{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	changes := models.Changes{Revision: since}
+	var i, j int
+	for n := 0; n < limit && (i < len(data) || j < len(tombstones)); n++ {
+		if j == len(tombstones) || (i < len(data) && data[i].Revision < tombstones[j].Revision) {
+			record, err := openData(secretKey, userID, data[i])
+			if err != nil {
+				log.Error("failed to decrypt secret", slog.Int64("id", data[i].ID), logger.Err(err))
+				return models.Changes{}, fmt.Errorf("%s:%w", op, err)
+			}
+			changes.Records = append(changes.Records, record)
+			changes.Revision = record.Revision
+			i++
+			continue
+		}
+		changes.Deleted = append(changes.Deleted, tombstones[j])
+		changes.Revision = tombstones[j].Revision
+		j++
+	}
+	changes.HasMore = i < len(data) || j < len(tombstones)
+
+	return changes, nil
+}
+
+// SubscribeChanges returns channel notified after records of the current user change
+// and the function to cancel the subscription. Changes themselves are read with Sync.
+func (s *Service) SubscribeChanges(ctx context.Context) (<-chan struct{}, func(), error) {
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return nil, nil, customerr.ErrFailedGetUserID
+	}
+
+	ch, cancel := s.changes.Subscribe(userID)
+	return ch, cancel, nil
+}
@@ -0,0 +1,136 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// ListTrash returns deleted records of the current user which are not purged yet.
+func (s *Service) ListTrash(ctx context.Context) ([]models.Record, error) {
+	const op = "service.Keeper.ListTrash"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.storage.ListTrash(ctx, userID)
+	if err != nil {
+		log.Error("failed to list trash", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	records := make([]models.Record, 0, len(data))
+	for _, v := range data {
+		if !inScope(ctx, v) {
+			continue
+		}
+		record, err := openData(secretKey, userID, v)
+		if err != nil {
+			log.Error("failed to decrypt secret", slog.Int64("id", v.ID), logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// RestoreFromTrash returns a deleted record of the current user back to its records.
+func (s *Service) RestoreFromTrash(ctx context.Context, id int64) (models.Record, error) {
+	const op = "service.Keeper.RestoreFromTrash"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	if scopeTag(ctx) != "" {
+		// Токен с тегом восстанавливает только свои записи
+		trashed, err := s.storage.GetTrashedData(ctx, id, userID)
+		if err != nil {
+			if !errors.Is(err, customerr.ErrDataNotFound) {
+				log.Error("failed to get trashed data", logger.Err(err))
+			}
+			return models.Record{}, fmt.Errorf("%s:%w", op, err)
+		}
+		if !inScope(ctx, trashed) {
+			return models.Record{}, fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+		}
+	}
+
+	data, err := s.storage.RestoreData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to restore data", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.changes.Publish(userID)
+
+	record, err := openData(secretKey, userID, data)
+	if err != nil {
+		log.Error("failed to decrypt secret", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return record, nil
+}
+
+// PurgeTrash removes records deleted longer than retention ago and returns the number of removed records.
+// Their file content is queued for RemovePurgedBlobs.
+func (s *Service) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	const op = "service.Keeper.PurgeTrash"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	purged, err := s.storage.PurgeTrash(ctx, time.Now().Add(-retention))
+	if err != nil {
+		log.Error("failed to purge trash", logger.Err(err))
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	return purged, nil
+}
+
+// RemovePurgedBlobs removes file content of purged records and deleted accounts from the blob store
+// and returns the number of removed blobs. Blobs which fail to be removed are retried on the next run.
+func (s *Service) RemovePurgedBlobs(ctx context.Context) (int64, error) {
+	const op = "service.Keeper.RemovePurgedBlobs"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	blobIDs, err := s.storage.ListPurgedBlobs(ctx)
+	if err != nil {
+		log.Error("failed to list purged blobs", logger.Err(err))
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	var removed int64
+	for _, blobID := range blobIDs {
+		// Блоб остаётся в очереди, если удалить его не удалось
+		if err = s.blobs.DeleteBlob(ctx, blobID); err != nil {
+			log.Error("failed to delete file content", slog.String("blobID", blobID), logger.Err(err))
+			continue
+		}
+		if err = s.storage.DeletePurgedBlob(ctx, blobID); err != nil {
+			log.Error("failed to forget purged blob", slog.String("blobID", blobID), logger.Err(err))
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,251 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/totp"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+const (
+	totpIssuer   = "Gophkeeper"
+	challengeTTL = 5 * time.Minute
+)
+
+// totpAD binds the encrypted TOTP secret to its purpose.
+var totpAD = []byte("totp")
+
+// Enable2FA generates a new TOTP secret for the current user. Two-factor authentication
+// is turned on only after Confirm2FA with a code from the authenticator app.
+func (s *Service) Enable2FA(ctx context.Context) (models.TOTPEnrollment, error) {
+	const op = "service.Auth.Enable2FA"
+
+	userID, key, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, err)
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, err)
+	}
+	if user.TOTPEnabled {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, customerr.ErrTwoFactorEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("failed to generate totp secret", logger.Err(err))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Секрет хранится зашифрованным ключом пользователя
+	encrypted, err := seal(key, secret, totpAD)
+	if err != nil {
+		log.Error("failed to encrypt totp secret", logger.Err(err))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = s.storage.UpdateTOTP(ctx, userID, encrypted, false); err != nil {
+		log.Error("failed to store totp secret", logger.Err(err))
+		return models.TOTPEnrollment{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return models.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm2FA turns on two-factor authentication if code matches the secret from Enable2FA.
+func (s *Service) Confirm2FA(ctx context.Context, code string) error {
+	const op = "service.Auth.Confirm2FA"
+
+	userID, key, err := s.sessionKey(ctx)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if user.TOTPEnabled {
+		return fmt.Errorf("%s:%w", op, customerr.ErrTwoFactorEnabled)
+	}
+	if len(user.TOTPSecret) == 0 {
+		return fmt.Errorf("%s:%w", op, customerr.ErrTwoFactorDisabled)
+	}
+
+	if err = s.verifyTOTP(ctx, log, user, key, code); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = s.storage.UpdateTOTP(ctx, userID, user.TOTPSecret, true); err != nil {
+		log.Error("failed to enable totp", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("two-factor authentication enabled")
+	return nil
+}
+
+// Disable2FA turns off two-factor authentication, a valid code is required.
+func (s *Service) Disable2FA(ctx context.Context, code string) error {
+	const op = "service.Auth.Disable2FA"
+
+	userID, key, err := s.sessionKey(ctx)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("%s:%w", op, customerr.ErrTwoFactorDisabled)
+	}
+
+	if err = s.verifyTOTP(ctx, log, user, key, code); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err = s.storage.UpdateTOTP(ctx, userID, nil, false); err != nil {
+		log.Error("failed to disable totp", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("two-factor authentication disabled")
+	return nil
+}
+
+// LoginTwoFactor finishes login started by Login with a one-time code.
+// The challenge allows a single attempt, after a wrong code login starts over with the password.
+func (s *Service) LoginTwoFactor(ctx context.Context, challengeToken string, code string, device models.Device) (models.Tokens, error) {
+	const op = "service.Auth.LoginTwoFactor"
+
+	claims, err := s.tokens.ParseChallengeToken(challengeToken)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", claims.UserID))
+
+	// Challenge удаляется сразу, даже если код окажется неверным
+	key, ok := s.keyring.Take(claims.SessionID, claims.UserID)
+	if !ok {
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+
+	user, err := s.storage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	ip := core.GetContextClientIP(ctx)
+	attempt, err := s.beginLogin(user.Email, ip)
+	if err != nil {
+		log.Info("login blocked", slog.String("ip", ip), logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer s.endLogin(attempt)
+
+	if err = s.checkTOTP(ctx, user, key, code); err != nil {
+		log.Info("invalid one-time code")
+		if errors.Is(err, customerr.ErrInvalidTOTPCode) {
+			s.loginFailed(ctx, log, attempt, user.ID)
+		}
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+	s.limits.Account.Reset(accountKey(user.Email))
+
+	tokens, err := s.newSession(ctx, user, key, device)
+	if err != nil {
+		log.Error("failed to create session", logger.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("user logged in with second factor")
+	return tokens, nil
+}
+
+// newChallenge keeps secret key unlocked by the password until the second factor is checked.
+func (s *Service) newChallenge(userID int64, secretKey []byte) (string, error) {
+	challengeID, err := newSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.tokens.NewChallengeToken(userID, challengeID, challengeTTL)
+	if err != nil {
+		return "", err
+	}
+
+	s.keyring.Put(challengeID, userID, secretKey, challengeTTL)
+	return token, nil
+}
+
+// verifyTOTP checks the code of a logged in user. Wrong codes are counted the same way as in LoginTwoFactor,
+// so a stolen access token can not be used to guess the code.
+func (s *Service) verifyTOTP(ctx context.Context, log *slog.Logger, user *models.User, key []byte, code string) error {
+	ip := core.GetContextClientIP(ctx)
+	attempt, err := s.beginLogin(user.Email, ip)
+	if err != nil {
+		log.Info("one-time code check blocked", slog.String("ip", ip), logger.Err(err))
+		return err
+	}
+	defer s.endLogin(attempt)
+
+	if err = s.checkTOTP(ctx, user, key, code); err != nil {
+		if errors.Is(err, customerr.ErrInvalidTOTPCode) {
+			log.Info("invalid one-time code")
+			s.loginFailed(ctx, log, attempt, user.ID)
+		}
+		return err
+	}
+	s.limits.Account.Reset(accountKey(user.Email))
+	return nil
+}
+
+// checkTOTP validates code and marks its step as used.
+func (s *Service) checkTOTP(ctx context.Context, user *models.User, key []byte, code string) error {
+	secret, err := open(key, user.TOTPSecret, totpAD)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return customerr.ErrInvalidTOTPCode
+	}
+
+	if err = s.storage.UseTOTPStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, customerr.ErrInvalidTOTPCode) {
+			return customerr.ErrInvalidTOTPCode
+		}
+		return err
+	}
+	return nil
+}
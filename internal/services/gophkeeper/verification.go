@@ -0,0 +1,79 @@
+package gophkeeper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/mailer"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// VerifyEmail confirms the email of the account with the code sent after registration.
+// Every code is accepted only once.
+func (s *Service) VerifyEmail(ctx context.Context, code string) error {
+	const op = "service.Auth.VerifyEmail"
+
+	log := s.logger.With(
+		slog.String("op", op))
+
+	claims, err := s.tokens.ParseEmailVerificationToken(code)
+	if err != nil {
+		log.Info("invalid verification code", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, customerr.ErrInvalidToken)
+	}
+	log = log.With(slog.Int64("userID", claims.UserID))
+
+	if err = s.storage.VerifyEmail(ctx, claims.UserID, claims.Email); err != nil {
+		log.Info("failed to verify email", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("email verified")
+	return nil
+}
+
+// SendVerificationEmail sends a new verification code to the email of the current user.
+func (s *Service) SendVerificationEmail(ctx context.Context) error {
+	const op = "service.Auth.SendVerificationEmail"
+
+	userID := core.GetContextUserID(ctx)
+	if userID == 0 {
+		return customerr.ErrFailedGetUserID
+	}
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err := s.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return fmt.Errorf("%s:%w", op, customerr.ErrEmailVerified)
+	}
+
+	if err = s.sendVerificationEmail(ctx, user.ID, user.Email); err != nil {
+		log.Error("failed to send verification email", logger.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+func (s *Service) sendVerificationEmail(ctx context.Context, userID int64, email string) error {
+	code, err := s.tokens.NewEmailVerificationToken(userID, email, s.verifyTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Confirm your GophKeeper email",
+		Body: fmt.Sprintf("Use this code to confirm your email address:\n\n%s\n\n"+
+			"The code is valid for %s. If you did not create a GophKeeper account, ignore this email.\n",
+			code, s.verifyTTL),
+	})
+}
@@ -0,0 +1,112 @@
+package gophkeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	customerr "github.com/gtngzlv/gophkeeper-server/internal/domain/errors"
+	"github.com/gtngzlv/gophkeeper-server/internal/domain/models"
+	"github.com/gtngzlv/gophkeeper-server/internal/logger"
+)
+
+// ListRecordVersions returns previous versions of the current user record, newest first.
+func (s *Service) ListRecordVersions(ctx context.Context, id int64) ([]models.Record, error) {
+	const op = "service.Keeper.ListRecordVersions"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.storage.GetData(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataNotFound) {
+			log.Error("failed to get data", logger.Err(err))
+		}
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	if !inScope(ctx, current) {
+		return nil, fmt.Errorf("%s:%w", op, customerr.ErrDataNotFound)
+	}
+
+	versions, err := s.storage.ListDataVersions(ctx, id, userID)
+	if err != nil {
+		log.Error("failed to list versions", logger.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	records := make([]models.Record, 0, len(versions))
+	for _, v := range versions {
+		record, err := openData(secretKey, userID, v)
+		if err != nil {
+			log.Error("failed to decrypt version", slog.Int64("version", v.Version), logger.Err(err))
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// RestoreRecordVersion makes a previous version of the record current again. Restoring is an update:
+// the record gets a new version and the replaced one is kept in the history.
+// When the record no longer has expectedVersion ConflictError with the current record is returned.
+func (s *Service) RestoreRecordVersion(ctx context.Context, id int64, version int64, expectedVersion int64) (models.Record, error) {
+	const op = "service.Keeper.RestoreRecordVersion"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Int64("id", id),
+		slog.Int64("version", version))
+
+	userID, secretKey, err := s.sessionKey(ctx)
+	if err != nil {
+		return models.Record{}, err
+	}
+
+	old, err := s.storage.GetDataVersion(ctx, id, userID, version)
+	if err != nil {
+		if !errors.Is(err, customerr.ErrDataVersionNotFound) {
+			log.Error("failed to get version", logger.Err(err))
+		}
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Версия могла быть зашифрована до перехода записи на ключ данных, поэтому шифруем заново
+	record, err := openData(secretKey, userID, old)
+	if err != nil {
+		log.Error("failed to decrypt version", logger.Err(err))
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	restored, err := s.UpdateData(ctx, id, expectedVersion, record.Secret, record.Attributes)
+	if err != nil {
+		return models.Record{}, fmt.Errorf("%s:%w", op, err)
+	}
+	log.Info("record version restored")
+	return restored, nil
+}
+
+// PruneVersions removes record versions beyond the keep newest ones and versions older than maxAge.
+// Zero keep or maxAge disables the corresponding limit.
+func (s *Service) PruneVersions(ctx context.Context, keep int, maxAge time.Duration) (int64, error) {
+	const op = "service.Keeper.PruneVersions"
+
+	var before time.Time
+	if maxAge > 0 {
+		before = time.Now().Add(-maxAge)
+	}
+
+	deleted, err := s.storage.PruneDataVersions(ctx, keep, before)
+	if err != nil {
+		s.logger.Error("failed to prune versions", slog.String("op", op), logger.Err(err))
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS UPDATED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_user_id ON PERSONAL_DATA(USER_ID, ID);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_personal_data_user_id;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS UPDATED_AT;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS KIND TEXT NOT NULL DEFAULT 'text';
+
+ALTER TABLE PERSONAL_DATA ADD CONSTRAINT personal_data_kind_check
+    CHECK (KIND IN ('credentials', 'text', 'card', 'binary'));
+
+-- Existing rows are plain text notes, keep them in the same JSON shape as new ones.
+ALTER TABLE PERSONAL_DATA ALTER COLUMN PDATA TYPE BYTEA
+    USING convert_to(json_build_object('text', PDATA)::TEXT, 'UTF8');
+
+ALTER TABLE PERSONAL_DATA ALTER COLUMN KIND DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE PERSONAL_DATA ALTER COLUMN PDATA TYPE TEXT USING convert_from(PDATA, 'UTF8');
+ALTER TABLE PERSONAL_DATA DROP CONSTRAINT IF EXISTS personal_data_kind_check;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS KIND;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS PERSONAL_DATA_META(
+    DATA_ID INT NOT NULL REFERENCES PERSONAL_DATA(ID) ON DELETE CASCADE,
+    KEY TEXT NOT NULL,
+    VALUE TEXT NOT NULL,
+    PRIMARY KEY (DATA_ID, KEY));
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_meta_key ON PERSONAL_DATA_META(KEY, DATA_ID);
+
+CREATE TABLE IF NOT EXISTS PERSONAL_DATA_TAGS(
+    DATA_ID INT NOT NULL REFERENCES PERSONAL_DATA(ID) ON DELETE CASCADE,
+    TAG TEXT NOT NULL,
+    PRIMARY KEY (DATA_ID, TAG));
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_tags_tag ON PERSONAL_DATA_TAGS(TAG, DATA_ID);
+
+-- +goose Down
+DROP TABLE IF EXISTS PERSONAL_DATA_TAGS;
+DROP TABLE IF EXISTS PERSONAL_DATA_META;
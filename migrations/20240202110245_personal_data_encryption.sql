@@ -0,0 +1,10 @@
+-- +goose Up
+-- Data key of the record wrapped by the owner secret key.
+-- NULL marks legacy plaintext rows, they are encrypted on the next owner login.
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS WRAPPED_KEY BYTEA;
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_unencrypted ON PERSONAL_DATA(USER_ID) WHERE WRAPPED_KEY IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_personal_data_unencrypted;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS WRAPPED_KEY;
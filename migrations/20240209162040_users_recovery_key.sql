@@ -0,0 +1,6 @@
+-- +goose Up
+-- Secret key wrapped by the recovery code, NULL when the user opted out.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_key BYTEA;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_key;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT NOT NULL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    -- SHA-256 of the current refresh token and of the one it replaced, to detect reuse
+    refresh_hash BYTEA NOT NULL UNIQUE,
+    previous_hash BYTEA,
+    -- Secret key wrapped by the refresh token, cleared on revocation
+    wrapped_key BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_hash ON sessions(previous_hash);
+
+-- +goose Down
+DROP TABLE IF EXISTS sessions;
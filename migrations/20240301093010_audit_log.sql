@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    -- NULL when the event is not bound to an existing user, e.g. login attempts for unknown email
+    user_id INT REFERENCES users(id),
+    event TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
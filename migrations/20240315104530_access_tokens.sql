@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS access_tokens (
+    id TEXT NOT NULL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    -- SHA-256 of the token secret
+    secret_hash BYTEA NOT NULL,
+    -- Secret key wrapped by the token secret, cleared on revocation
+    wrapped_key BYTEA,
+    read_only BOOLEAN NOT NULL DEFAULT TRUE,
+    tag TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON access_tokens(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS access_tokens;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- NULL until the user confirms the email with the code sent after registration.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Per-user counter incremented by every write, records and tombstones keep the revision of their last change.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS REVISION BIGINT NOT NULL DEFAULT 0;
+
+-- Existing records get distinct revisions, so clients syncing from the start receive all of them
+UPDATE PERSONAL_DATA pd SET REVISION = r.rn
+FROM (SELECT ID, ROW_NUMBER() OVER (PARTITION BY USER_ID ORDER BY ID) AS rn FROM PERSONAL_DATA) r
+WHERE pd.ID = r.ID;
+UPDATE users SET revision = COALESCE((SELECT MAX(pd.REVISION) FROM PERSONAL_DATA pd WHERE pd.USER_ID = users.id), 0);
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_revision ON PERSONAL_DATA(USER_ID, REVISION);
+
+CREATE TABLE IF NOT EXISTS PERSONAL_DATA_TOMBSTONES(
+    DATA_ID INT NOT NULL PRIMARY KEY,
+    USER_ID INT NOT NULL REFERENCES USERS(ID),
+    REVISION BIGINT NOT NULL,
+    DELETED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_tombstones_revision ON PERSONAL_DATA_TOMBSTONES(USER_ID, REVISION);
+
+-- +goose Down
+DROP TABLE IF EXISTS PERSONAL_DATA_TOMBSTONES;
+DROP INDEX IF EXISTS idx_personal_data_revision;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS REVISION;
+ALTER TABLE users DROP COLUMN IF EXISTS revision;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Incremented by every update, clients pass the version they have seen to detect concurrent changes.
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS VERSION BIGINT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS VERSION;
@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS uploads (
+    id TEXT NOT NULL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    size BIGINT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    -- Name, checksum and attributes encrypted with the data key
+    meta BYTEA NOT NULL,
+    received BIGINT NOT NULL DEFAULT 0,
+    hash_state BYTEA,
+    chunk_size INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user_id ON uploads(user_id);
+
+-- Content of file records, chunks are kept in the blob store under this ID
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS BLOB_ID TEXT NOT NULL DEFAULT '';
+ALTER TABLE PERSONAL_DATA DROP CONSTRAINT IF EXISTS personal_data_kind_check;
+ALTER TABLE PERSONAL_DATA ADD CONSTRAINT personal_data_kind_check
+    CHECK (KIND IN ('credentials', 'text', 'card', 'binary', 'file'));
+
+-- Chunks of files kept by the postgres blob store as large objects
+CREATE TABLE IF NOT EXISTS blob_chunks (
+    blob_id TEXT NOT NULL,
+    idx INT NOT NULL,
+    oid OID NOT NULL,
+    PRIMARY KEY (blob_id, idx)
+);
+
+-- +goose Down
+SELECT lo_unlink(oid) FROM blob_chunks;
+DROP TABLE IF EXISTS blob_chunks;
+DELETE FROM PERSONAL_DATA WHERE KIND = 'file';
+ALTER TABLE PERSONAL_DATA DROP CONSTRAINT IF EXISTS personal_data_kind_check;
+ALTER TABLE PERSONAL_DATA ADD CONSTRAINT personal_data_kind_check
+    CHECK (KIND IN ('credentials', 'text', 'card', 'binary'));
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS BLOB_ID;
+DROP TABLE IF EXISTS uploads;
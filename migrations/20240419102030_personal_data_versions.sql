@@ -0,0 +1,18 @@
+-- +goose Up
+-- Previous versions of records, written by every update. Metadata values stay encrypted with the record data key.
+CREATE TABLE IF NOT EXISTS PERSONAL_DATA_VERSIONS(
+    DATA_ID INT NOT NULL REFERENCES PERSONAL_DATA(ID) ON DELETE CASCADE,
+    VERSION BIGINT NOT NULL,
+    KIND TEXT NOT NULL,
+    PDATA BYTEA NOT NULL,
+    WRAPPED_KEY BYTEA,
+    METADATA JSONB NOT NULL DEFAULT '{}',
+    TAGS TEXT[] NOT NULL DEFAULT '{}',
+    UPDATED_AT TIMESTAMP WITH TIME ZONE NOT NULL,
+    ARCHIVED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (DATA_ID, VERSION));
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_versions_archived_at ON PERSONAL_DATA_VERSIONS(ARCHIVED_AT);
+
+-- +goose Down
+DROP TABLE IF EXISTS PERSONAL_DATA_VERSIONS;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Deleted records stay in the trash until DELETED_AT is older than the retention period.
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS DELETED_AT TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_deleted_at ON PERSONAL_DATA(DELETED_AT) WHERE DELETED_AT IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_personal_data_deleted_at;
+DELETE FROM PERSONAL_DATA WHERE DELETED_AT IS NOT NULL;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS DELETED_AT;
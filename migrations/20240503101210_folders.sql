@@ -0,0 +1,28 @@
+-- +goose Up
+-- Folders of a user. NAME is encrypted with the folder data key, which is wrapped by the user secret key.
+-- Composite foreign keys keep parents and records in folders of the same user.
+CREATE TABLE IF NOT EXISTS FOLDERS(
+    ID SERIAL PRIMARY KEY,
+    USER_ID INT NOT NULL REFERENCES USERS(ID),
+    PARENT_ID INT,
+    NAME BYTEA NOT NULL,
+    WRAPPED_KEY BYTEA NOT NULL,
+    CREATED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UPDATED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (ID, USER_ID),
+    FOREIGN KEY (PARENT_ID, USER_ID) REFERENCES FOLDERS(ID, USER_ID),
+    CHECK (PARENT_ID <> ID));
+
+CREATE INDEX IF NOT EXISTS idx_folders_parent ON FOLDERS(USER_ID, PARENT_ID);
+
+ALTER TABLE PERSONAL_DATA ADD COLUMN IF NOT EXISTS FOLDER_ID INT;
+ALTER TABLE PERSONAL_DATA ADD CONSTRAINT personal_data_folder_fk
+    FOREIGN KEY (FOLDER_ID, USER_ID) REFERENCES FOLDERS(ID, USER_ID);
+
+CREATE INDEX IF NOT EXISTS idx_personal_data_folder ON PERSONAL_DATA(USER_ID, FOLDER_ID);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_personal_data_folder;
+ALTER TABLE PERSONAL_DATA DROP CONSTRAINT IF EXISTS personal_data_folder_fk;
+ALTER TABLE PERSONAL_DATA DROP COLUMN IF EXISTS FOLDER_ID;
+DROP TABLE IF EXISTS FOLDERS;
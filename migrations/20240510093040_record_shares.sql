@@ -0,0 +1,23 @@
+-- +goose Up
+-- X25519 keypair of every user, the private key is encrypted with the user secret key.
+-- Users registered before get the keypair on their next login.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS public_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS private_key BYTEA;
+
+-- Data keys of shared records wrapped for the recipient public key.
+CREATE TABLE IF NOT EXISTS RECORD_SHARES(
+    DATA_ID INT NOT NULL REFERENCES PERSONAL_DATA(ID) ON DELETE CASCADE,
+    RECIPIENT_ID INT NOT NULL REFERENCES USERS(ID),
+    OWNER_ID INT NOT NULL REFERENCES USERS(ID),
+    WRAPPED_KEY BYTEA NOT NULL,
+    PERMISSION TEXT NOT NULL CHECK (PERMISSION IN ('read', 'write')),
+    CREATED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (DATA_ID, RECIPIENT_ID));
+
+CREATE INDEX IF NOT EXISTS idx_record_shares_recipient ON RECORD_SHARES(RECIPIENT_ID);
+CREATE INDEX IF NOT EXISTS idx_record_shares_owner ON RECORD_SHARES(OWNER_ID);
+
+-- +goose Down
+DROP TABLE IF EXISTS RECORD_SHARES;
+ALTER TABLE users DROP COLUMN IF EXISTS private_key;
+ALTER TABLE users DROP COLUMN IF EXISTS public_key;
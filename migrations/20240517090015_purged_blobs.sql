@@ -0,0 +1,9 @@
+-- +goose Up
+-- Blobs of purged records and deleted accounts, removed from the blob store after the records are gone.
+-- Blobs which failed to be removed stay here and are retried on the next run.
+CREATE TABLE IF NOT EXISTS PURGED_BLOBS(
+    BLOB_ID TEXT PRIMARY KEY,
+    PURGED_AT TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL);
+
+-- +goose Down
+DROP TABLE IF EXISTS PURGED_BLOBS;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Abandoned uploads of all users are looked up by expiry in the background job.
+CREATE INDEX IF NOT EXISTS idx_uploads_expires_at ON uploads(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_uploads_expires_at;
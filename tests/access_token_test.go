@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestAccessToken_ScopedByTag(t *testing.T) {
+	ctx, st := suite.New(t)
+	userCtx := loginNewUser(ctx, t, st)
+
+	respCI, err := st.Client.SaveData(userCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+		Tags:    []string{"ci"},
+	})
+	require.NoError(t, err)
+	ciID := respCI.GetIds()[0]
+
+	respHome, err := st.Client.SaveData(userCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+		Tags:    []string{"home"},
+	})
+	require.NoError(t, err)
+	homeID := respHome.GetIds()[0]
+
+	respToken, err := st.Client.CreateAccessToken(userCtx, &pb.CreateAccessTokenRequest{Name: "deploy", Tag: "ci"})
+	require.NoError(t, err)
+	require.NotEmpty(t, respToken.GetToken())
+	assert.Equal(t, "ci", respToken.GetAccessToken().GetTag())
+	tokenCtx := suite.WithToken(ctx, respToken.GetToken())
+
+	respList, err := st.Client.ListData(tokenCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetRecords(), 1)
+	assert.Equal(t, ciID, respList.GetRecords()[0].GetId())
+
+	_, err = st.Client.GetData(tokenCtx, &pb.GetDataRequest{Id: homeID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	respSave, err := st.Client.SaveData(tokenCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+	})
+	require.NoError(t, err)
+	respGet, err := st.Client.GetData(userCtx, &pb.GetDataRequest{Id: respSave.GetIds()[0]})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci"}, respGet.GetRecord().GetTags())
+
+	// Токен не даёт доступа к управлению аккаунтом
+	_, err = st.Client.ListSessions(tokenCtx, &pb.ListSessionsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAccessToken_ReadOnlyAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+	userCtx := loginNewUser(ctx, t, st)
+
+	respToken, err := st.Client.CreateAccessToken(userCtx, &pb.CreateAccessTokenRequest{Name: "backup", ReadOnly: true})
+	require.NoError(t, err)
+	tokenCtx := suite.WithToken(ctx, respToken.GetToken())
+
+	_, err = st.Client.ListData(tokenCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+
+	_, err = st.Client.SaveData(tokenCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	respList, err := st.Client.ListAccessTokens(userCtx, &pb.ListAccessTokensRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetAccessTokens(), 1)
+	assert.Equal(t, respToken.GetAccessToken().GetId(), respList.GetAccessTokens()[0].GetId())
+	assert.NotNil(t, respList.GetAccessTokens()[0].GetLastUsedAt())
+
+	_, err = st.Client.RevokeAccessToken(userCtx, &pb.RevokeAccessTokenRequest{Id: respToken.GetAccessToken().GetId()})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListData(tokenCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	respList, err = st.Client.ListAccessTokens(userCtx, &pb.ListAccessTokensRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, respList.GetAccessTokens())
+}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
 
-	libjwt "github.com/gtngzlv/gophkeeper-server/internal/lib/core"
 	"github.com/gtngzlv/gophkeeper-server/tests/suite"
 )
 
@@ -21,7 +20,7 @@ func TestRegisterLogin_Login_Success(t *testing.T) {
 	email := gofakeit.Email()
 	password := fakePassword()
 
-	respRegister, err := st.AuthClient.Register(ctx, &pb.RegisterRequest{
+	respRegister, err := st.Client.Register(ctx, &pb.RegisterRequest{
 		Email:    email,
 		Password: password,
 	})
@@ -30,7 +29,7 @@ func TestRegisterLogin_Login_Success(t *testing.T) {
 
 	assert.NotEmpty(t, respRegister.GetUserId())
 
-	respLogin, err := st.AuthClient.Login(ctx, &pb.LoginRequest{
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{
 		Email:    email,
 		Password: password,
 	})
@@ -41,9 +40,7 @@ func TestRegisterLogin_Login_Success(t *testing.T) {
 	token := respLogin.GetToken()
 	require.NotEmpty(t, token)
 
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte(libjwt.Secret), nil
-	})
+	parsedToken, err := jwt.Parse(token, st.Keyfunc)
 	require.NoError(t, err)
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
@@ -63,7 +60,7 @@ func TestRegisterLogin_LoginWithIncorrectPassword_Failed(t *testing.T) {
 	email := gofakeit.Email()
 	registerPassword := fakePassword()
 
-	respRegister, err := st.AuthClient.Register(ctx, &pb.RegisterRequest{
+	respRegister, err := st.Client.Register(ctx, &pb.RegisterRequest{
 		Email:    email,
 		Password: registerPassword,
 	})
@@ -73,7 +70,7 @@ func TestRegisterLogin_LoginWithIncorrectPassword_Failed(t *testing.T) {
 	assert.NotEmpty(t, respRegister.GetUserId())
 
 	loginPassword := fakePassword()
-	_, err = st.AuthClient.Login(ctx, &pb.LoginRequest{
+	_, err = st.Client.Login(ctx, &pb.LoginRequest{
 		Email:    email,
 		Password: loginPassword,
 	})
@@ -86,7 +83,7 @@ func TestRegisterLogin_DuplicateRegisterRequest_And_Login(t *testing.T) {
 	email := gofakeit.Email()
 	password := fakePassword()
 
-	firstRespRegister, err := st.AuthClient.Register(ctx, &pb.RegisterRequest{
+	firstRespRegister, err := st.Client.Register(ctx, &pb.RegisterRequest{
 		Email:    email,
 		Password: password,
 	})
@@ -95,14 +92,14 @@ func TestRegisterLogin_DuplicateRegisterRequest_And_Login(t *testing.T) {
 
 	assert.NotEmpty(t, firstRespRegister.GetUserId())
 
-	_, err = st.AuthClient.Register(ctx, &pb.RegisterRequest{
+	_, err = st.Client.Register(ctx, &pb.RegisterRequest{
 		Email:    email,
 		Password: password,
 	})
 
 	require.Error(t, err)
 
-	respLogin, err := st.AuthClient.Login(ctx, &pb.LoginRequest{
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{
 		Email:    email,
 		Password: password,
 	})
@@ -113,9 +110,7 @@ func TestRegisterLogin_DuplicateRegisterRequest_And_Login(t *testing.T) {
 	token := respLogin.GetToken()
 	require.NotEmpty(t, token)
 
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte(libjwt.Secret), nil
-	})
+	parsedToken, err := jwt.Parse(token, st.Keyfunc)
 	require.NoError(t, err)
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestBlob_ResumableUpload_Download(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	// Чуть больше двух чанков, последний неполный
+	content := randomContent(t, 2<<20+100)
+	checksum := sha256.Sum256(content)
+	name := gofakeit.Word() + ".bin"
+
+	respCreate, err := st.Client.CreateUpload(ctx, &pb.CreateUploadRequest{
+		Name:   name,
+		Size:   int64(len(content)),
+		Sha256: hex.EncodeToString(checksum[:]),
+		Tags:   []string{"files"},
+	})
+	require.NoError(t, err)
+	upload := respCreate.GetUpload()
+	chunkSize := int(upload.GetChunkSize())
+	require.Positive(t, chunkSize)
+
+	// Первый поток обрывается после одного чанка
+	respFirst := uploadChunks(ctx, t, st, upload.GetUploadId(), content, 0, chunkSize)
+	assert.Equal(t, int64(chunkSize), respFirst.GetUpload().GetReceived())
+	assert.Nil(t, respFirst.GetRecord())
+
+	respProgress, err := st.Client.GetUpload(ctx, &pb.GetUploadRequest{UploadId: upload.GetUploadId()})
+	require.NoError(t, err)
+	received := respProgress.GetUpload().GetReceived()
+	require.Equal(t, int64(chunkSize), received)
+
+	respLast := uploadChunks(ctx, t, st, upload.GetUploadId(), content, received, len(content))
+	record := respLast.GetRecord()
+	require.NotNil(t, record)
+	assert.Equal(t, name, record.GetSecret().GetFile().GetName())
+	assert.Equal(t, int64(len(content)), record.GetSecret().GetFile().GetSize())
+	assert.Equal(t, []string{"files"}, record.GetTags())
+
+	_, err = st.Client.GetUpload(ctx, &pb.GetUploadRequest{UploadId: upload.GetUploadId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	file, downloaded := downloadFile(ctx, t, st, record.GetId(), 0)
+	assert.Equal(t, hex.EncodeToString(checksum[:]), file.GetSha256())
+	assert.Equal(t, content, downloaded)
+
+	offset := int64(chunkSize + 10)
+	_, tail := downloadFile(ctx, t, st, record.GetId(), offset)
+	assert.Equal(t, content[offset:], tail)
+
+	_, err = st.Client.DeleteData(ctx, &pb.DeleteDataRequest{Id: record.GetId(), ExpectedVersion: record.GetVersion()})
+	require.NoError(t, err)
+}
+
+func TestBlob_ChecksumMismatch(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	content := randomContent(t, 1000)
+	checksum := sha256.Sum256([]byte("something else"))
+
+	respCreate, err := st.Client.CreateUpload(ctx, &pb.CreateUploadRequest{
+		Name:   gofakeit.Word(),
+		Size:   int64(len(content)),
+		Sha256: hex.EncodeToString(checksum[:]),
+	})
+	require.NoError(t, err)
+	uploadID := respCreate.GetUpload().GetUploadId()
+
+	stream, err := st.Client.UploadBlob(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.UploadBlobRequest{UploadId: uploadID, Data: content}))
+	_, err = stream.CloseAndRecv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Повреждённая загрузка удаляется
+	_, err = st.Client.GetUpload(ctx, &pb.GetUploadRequest{UploadId: uploadID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestBlob_WrongOffset_FailedPrecondition(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	content := randomContent(t, 1000)
+	checksum := sha256.Sum256(content)
+
+	respCreate, err := st.Client.CreateUpload(ctx, &pb.CreateUploadRequest{
+		Name:   gofakeit.Word(),
+		Size:   int64(len(content)),
+		Sha256: hex.EncodeToString(checksum[:]),
+	})
+	require.NoError(t, err)
+
+	stream, err := st.Client.UploadBlob(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.UploadBlobRequest{
+		UploadId: respCreate.GetUpload().GetUploadId(),
+		Offset:   100,
+		Data:     content[100:],
+	}))
+	_, err = stream.CloseAndRecv()
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// uploadChunks sends content[from:to] in chunks of the upload in one stream.
+func uploadChunks(ctx context.Context, t *testing.T, st *suite.Suite, uploadID string, content []byte, from int64, to int) *pb.UploadBlobResponse {
+	t.Helper()
+
+	stream, err := st.Client.UploadBlob(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.UploadBlobRequest{UploadId: uploadID}))
+
+	respUpload, err := st.Client.GetUpload(ctx, &pb.GetUploadRequest{UploadId: uploadID})
+	require.NoError(t, err)
+	chunkSize := int64(respUpload.GetUpload().GetChunkSize())
+
+	for offset := from; offset < int64(to); offset += chunkSize {
+		end := min(offset+chunkSize, int64(to))
+		require.NoError(t, stream.Send(&pb.UploadBlobRequest{Offset: offset, Data: content[offset:end]}))
+	}
+
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	return resp
+}
+
+// downloadFile reads content of the file record from offset.
+func downloadFile(ctx context.Context, t *testing.T, st *suite.Suite, id int64, offset int64) (*pb.FileInfo, []byte) {
+	t.Helper()
+
+	stream, err := st.Client.DownloadBlob(ctx, &pb.DownloadBlobRequest{Id: id, Offset: offset})
+	require.NoError(t, err)
+
+	header, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, header.GetFile())
+
+	var content []byte
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, offset+int64(len(content)), resp.GetOffset())
+		content = append(content, resp.GetData()...)
+	}
+	return header.GetFile(), content
+}
+
+func randomContent(t *testing.T, size int) []byte {
+	t.Helper()
+
+	content := make([]byte, size)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+	return content
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestChangePassword_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, oldPassword := registerNewUser(ctx, t, st)
+	authCtx := login(ctx, t, st, email, oldPassword)
+	otherCtx := login(ctx, t, st, email, oldPassword)
+
+	value := gofakeit.Sentence(5)
+	respSave, err := st.Client.SaveData(authCtx, &pb.SaveDataRequest{Data: []string{value}})
+	require.NoError(t, err)
+
+	newPassword := fakePassword()
+	respChange, err := st.Client.ChangePassword(authCtx, &pb.ChangePasswordRequest{
+		OldPassword: oldPassword,
+		NewPassword: newPassword,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respChange.GetToken())
+
+	// Все старые сессии завершены
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.Client.ListData(otherCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Данные доступны с новым токеном
+	respGet, err := st.Client.GetData(suite.WithToken(ctx, respChange.GetToken()), &pb.GetDataRequest{
+		Id: respSave.GetIds()[0],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, value, respGet.GetRecord().GetSecret().GetText().GetText())
+
+	_, err = st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: oldPassword})
+	require.Error(t, err)
+
+	newCtx := login(ctx, t, st, email, newPassword)
+	_, err = st.Client.GetData(newCtx, &pb.GetDataRequest{Id: respSave.GetIds()[0]})
+	require.NoError(t, err)
+}
+
+func TestChangePassword_WrongOldPassword_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	authCtx := login(ctx, t, st, email, password)
+
+	_, err := st.Client.ChangePassword(authCtx, &pb.ChangePasswordRequest{
+		OldPassword: fakePassword(),
+		NewPassword: fakePassword(),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Сессия остаётся активной
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestUpdateData_VersionConflict(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	laptopCtx := login(ctx, t, st, email, password)
+	phoneCtx := login(ctx, t, st, email, password)
+
+	respSave, err := st.Client.SaveData(laptopCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+	})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	phoneLogin := gofakeit.Username()
+	_, err = st.Client.UpdateData(phoneCtx, &pb.UpdateDataRequest{
+		Id:              id,
+		Secret:          credentialsSecret(phoneLogin, fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+
+	// Ноутбук не видел изменений телефона
+	_, err = st.Client.UpdateData(laptopCtx, &pb.UpdateDataRequest{
+		Id:              id,
+		Secret:          credentialsSecret(gofakeit.Username(), fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	statusErr := status.Convert(err)
+	require.Len(t, statusErr.Details(), 1)
+	current, ok := statusErr.Details()[0].(*pb.Record)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), current.GetVersion())
+	assert.Equal(t, phoneLogin, current.GetSecret().GetCredentials().GetLogin())
+
+	_, err = st.Client.DeleteData(laptopCtx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: 1})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = st.Client.DeleteData(laptopCtx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: current.GetVersion()})
+	require.NoError(t, err)
+}
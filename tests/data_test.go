@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestSaveData_WithToken_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	resp, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{gofakeit.Sentence(5), gofakeit.Sentence(5)},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetIds(), 2)
+}
+
+func TestSaveData_WithoutToken_Unauthenticated(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{gofakeit.Sentence(5)},
+	})
+	require.Error(t, err)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSaveData_WithInvalidToken_Unauthenticated(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Client.SaveData(suite.WithToken(ctx, "not-a-token"), &pb.SaveDataRequest{
+		Data: []string{gofakeit.Sentence(5)},
+	})
+	require.Error(t, err)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestData_CRUD_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	value := gofakeit.Sentence(5)
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{Data: []string{value}})
+	require.NoError(t, err)
+	require.Len(t, respSave.GetIds(), 1)
+	id := respSave.GetIds()[0]
+
+	respGet, err := st.Client.GetData(ctx, &pb.GetDataRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, value, respGet.GetRecord().GetSecret().GetText().GetText())
+	assert.Equal(t, int64(1), respGet.GetRecord().GetVersion())
+
+	respList, err := st.Client.ListData(ctx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetRecords(), 1)
+	assert.Equal(t, id, respList.GetRecords()[0].GetId())
+	assert.Empty(t, respList.GetNextPageToken())
+
+	login, password := gofakeit.Username(), fakePassword()
+	respUpdate, err := st.Client.UpdateData(ctx, &pb.UpdateDataRequest{
+		Id:              id,
+		Secret:          credentialsSecret(login, password),
+		ExpectedVersion: respGet.GetRecord().GetVersion(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, login, respUpdate.GetRecord().GetSecret().GetCredentials().GetLogin())
+	assert.Equal(t, password, respUpdate.GetRecord().GetSecret().GetCredentials().GetPassword())
+	assert.Equal(t, int64(2), respUpdate.GetRecord().GetVersion())
+
+	_, err = st.Client.DeleteData(ctx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: respUpdate.GetRecord().GetVersion()})
+	require.NoError(t, err)
+
+	_, err = st.Client.GetData(ctx, &pb.GetDataRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListData_Pagination(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	_, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{gofakeit.Word(), gofakeit.Word(), gofakeit.Word()},
+	})
+	require.NoError(t, err)
+
+	first, err := st.Client.ListData(ctx, &pb.ListDataRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, first.GetRecords(), 2)
+	require.NotEmpty(t, first.GetNextPageToken())
+
+	second, err := st.Client.ListData(ctx, &pb.ListDataRequest{PageSize: 2, PageToken: first.GetNextPageToken()})
+	require.NoError(t, err)
+	require.Len(t, second.GetRecords(), 1)
+	assert.Empty(t, second.GetNextPageToken())
+}
+
+func TestGetData_OtherUser_NotFound(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	_, err = st.Client.GetData(otherCtx, &pb.GetDataRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.DeleteData(otherCtx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSaveData_TypedSecrets_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	card := &pb.BankCard{
+		Number: "4111 1111 1111 1111",
+		Expiry: "12/30",
+		Cvv:    "123",
+		Holder: gofakeit.Name(),
+	}
+	binary := &pb.BinaryData{Name: "key.pem", Data: []byte(gofakeit.LetterN(64))}
+
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{Secrets: []*pb.Secret{
+		{Payload: &pb.Secret_Card{Card: card}},
+		{Payload: &pb.Secret_Binary{Binary: binary}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, respSave.GetIds(), 2)
+
+	respCard, err := st.Client.GetData(ctx, &pb.GetDataRequest{Id: respSave.GetIds()[0]})
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", respCard.GetRecord().GetSecret().GetCard().GetNumber())
+	assert.Equal(t, card.GetHolder(), respCard.GetRecord().GetSecret().GetCard().GetHolder())
+
+	respBinary, err := st.Client.GetData(ctx, &pb.GetDataRequest{Id: respSave.GetIds()[1]})
+	require.NoError(t, err)
+	assert.Equal(t, binary.GetData(), respBinary.GetRecord().GetSecret().GetBinary().GetData())
+}
+
+func TestSaveData_InvalidCard_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	tests := []struct {
+		name string
+		card *pb.BankCard
+	}{
+		{name: "luhn", card: &pb.BankCard{Number: "4111111111111112", Expiry: "12/30"}},
+		{name: "expiry month", card: &pb.BankCard{Number: "4111111111111111", Expiry: "13/30"}},
+		{name: "expiry format", card: &pb.BankCard{Number: "4111111111111111", Expiry: "2030-12"}},
+		{name: "cvv", card: &pb.BankCard{Number: "4111111111111111", Expiry: "12/30", Cvv: "12a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{Secrets: []*pb.Secret{
+				{Payload: &pb.Secret_Card{Card: tt.card}},
+			}})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestListData_FilterByAttributes(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	respTagged, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Secrets:  []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+		Metadata: map[string]string{"url": gofakeit.URL()},
+		Tags:     []string{"work", "work", "vpn"},
+	})
+	require.NoError(t, err)
+	taggedID := respTagged.GetIds()[0]
+
+	_, err = st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+		Tags:    []string{"home"},
+	})
+	require.NoError(t, err)
+
+	respByTag, err := st.Client.ListData(ctx, &pb.ListDataRequest{Tag: "work"})
+	require.NoError(t, err)
+	require.Len(t, respByTag.GetRecords(), 1)
+	assert.Equal(t, taggedID, respByTag.GetRecords()[0].GetId())
+	assert.ElementsMatch(t, []string{"vpn", "work"}, respByTag.GetRecords()[0].GetTags())
+
+	respByKey, err := st.Client.ListData(ctx, &pb.ListDataRequest{MetadataKey: "url"})
+	require.NoError(t, err)
+	require.Len(t, respByKey.GetRecords(), 1)
+	assert.Equal(t, taggedID, respByKey.GetRecords()[0].GetId())
+	assert.NotEmpty(t, respByKey.GetRecords()[0].GetMetadata()["url"])
+}
+
+func credentialsSecret(login, password string) *pb.Secret {
+	return &pb.Secret{Payload: &pb.Secret_Credentials{Credentials: &pb.Credentials{
+		Login:    login,
+		Password: password,
+	}}}
+}
+
+// loginNewUser registers a random user and returns ctx authorized with its token.
+func loginNewUser(ctx context.Context, t *testing.T, st *suite.Suite) context.Context {
+	t.Helper()
+
+	email, password := registerNewUser(ctx, t, st)
+	return login(ctx, t, st, email, password)
+}
+
+// registerNewUser registers a random user and returns its credentials.
+func registerNewUser(ctx context.Context, t *testing.T, st *suite.Suite) (string, string) {
+	t.Helper()
+
+	email := gofakeit.Email()
+	password := fakePassword()
+
+	_, err := st.Client.Register(ctx, &pb.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	return email, password
+}
+
+// login returns ctx authorized with token of a new session.
+func login(ctx context.Context, t *testing.T, st *suite.Suite, email, password string) context.Context {
+	t.Helper()
+
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	return suite.WithToken(ctx, respLogin.GetToken())
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestDeleteAccount_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	authCtx := login(ctx, t, st, email, password)
+
+	_, err := st.Client.SaveData(authCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(gofakeit.Username(), fakePassword())},
+		Tags:    []string{"work"},
+	})
+	require.NoError(t, err)
+
+	respToken, err := st.Client.CreateAccessToken(authCtx, &pb.CreateAccessTokenRequest{Name: "ci"})
+	require.NoError(t, err)
+
+	_, err = st.Client.DeleteAccount(authCtx, &pb.DeleteAccountRequest{Password: password})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.Client.ListData(suite.WithToken(ctx, respToken.GetToken()), &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Email освобождается для новой регистрации
+	_, err = st.Client.Register(ctx, &pb.RegisterRequest{Email: email, Password: fakePassword()})
+	require.NoError(t, err)
+}
+
+func TestDeleteAccount_WrongPassword_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+	authCtx := loginNewUser(ctx, t, st)
+
+	_, err := st.Client.DeleteAccount(authCtx, &pb.DeleteAccountRequest{Password: fakePassword()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+}
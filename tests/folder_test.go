@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestFolders_Hierarchy(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	respWork, err := st.Client.CreateFolder(ctx, &pb.CreateFolderRequest{Name: "Work"})
+	require.NoError(t, err)
+	work := respWork.GetFolder()
+	assert.Equal(t, "Work", work.GetName())
+	assert.Zero(t, work.GetParentId())
+
+	respServers, err := st.Client.CreateFolder(ctx, &pb.CreateFolderRequest{Name: "Servers", ParentId: work.GetId()})
+	require.NoError(t, err)
+	servers := respServers.GetFolder()
+	assert.Equal(t, work.GetId(), servers.GetParentId())
+
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	respMove, err := st.Client.MoveRecord(ctx, &pb.MoveRecordRequest{Id: id, FolderId: servers.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, servers.GetId(), respMove.GetRecord().GetFolderId())
+	assert.Equal(t, int64(1), respMove.GetRecord().GetVersion())
+
+	respRoot, err := st.Client.ListFolder(ctx, &pb.ListFolderRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, respRoot.GetFolder())
+	require.Len(t, respRoot.GetFolders(), 1)
+	assert.Equal(t, work.GetId(), respRoot.GetFolders()[0].GetId())
+	assert.Empty(t, respRoot.GetRecords())
+
+	respList, err := st.Client.ListFolder(ctx, &pb.ListFolderRequest{Id: servers.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "Servers", respList.GetFolder().GetName())
+	require.Len(t, respList.GetRecords(), 1)
+	assert.Equal(t, id, respList.GetRecords()[0].GetId())
+
+	respRename, err := st.Client.RenameFolder(ctx, &pb.RenameFolderRequest{Id: servers.GetId(), Name: "Hosts"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hosts", respRename.GetFolder().GetName())
+
+	// Папку нельзя переместить внутрь неё самой
+	_, err = st.Client.MoveFolder(ctx, &pb.MoveFolderRequest{Id: work.GetId(), ParentId: servers.GetId()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	respMoveFolder, err := st.Client.MoveFolder(ctx, &pb.MoveFolderRequest{Id: servers.GetId()})
+	require.NoError(t, err)
+	assert.Zero(t, respMoveFolder.GetFolder().GetParentId())
+
+	_, err = st.Client.DeleteFolder(ctx, &pb.DeleteFolderRequest{Id: servers.GetId()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.Client.DeleteFolder(ctx, &pb.DeleteFolderRequest{Id: work.GetId()})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListFolder(ctx, &pb.ListFolderRequest{Id: work.GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFolders_OtherUser_NotFound(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respFolder, err := st.Client.CreateFolder(ownerCtx, &pb.CreateFolderRequest{Name: gofakeit.Word()})
+	require.NoError(t, err)
+	folderID := respFolder.GetFolder().GetId()
+
+	_, err = st.Client.ListFolder(otherCtx, &pb.ListFolderRequest{Id: folderID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.CreateFolder(otherCtx, &pb.CreateFolderRequest{Name: gofakeit.Word(), ParentId: folderID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	respSave, err := st.Client.SaveData(otherCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+
+	_, err = st.Client.MoveRecord(otherCtx, &pb.MoveRecordRequest{Id: respSave.GetIds()[0], FolderId: folderID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
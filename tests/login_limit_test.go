@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestLogin_AccountLockout(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+
+	// Бесплатные попытки и одна сверху, которая включает блокировку
+	for i := 0; i <= st.Cfg.LoginLimit.AccountAttempts; i++ {
+		_, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: fakePassword()})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// Даже верный пароль не принимается, пока аккаунт заблокирован
+	_, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	var retryDelay time.Duration
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			retryDelay = info.GetRetryDelay().AsDuration()
+		}
+	}
+	require.Greater(t, retryDelay, time.Duration(0))
+	assert.LessOrEqual(t, retryDelay, st.Cfg.LoginLimit.BaseDelay)
+
+	time.Sleep(retryDelay)
+
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	assert.NotEmpty(t, respLogin.GetToken())
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestRecoverAccount_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := fakePassword()
+
+	respReg, err := st.Client.Register(ctx, &pb.RegisterRequest{
+		Email:                email,
+		Password:             password,
+		GenerateRecoveryCode: true,
+	})
+	require.NoError(t, err)
+	code := respReg.GetRecoveryCode()
+	require.NotEmpty(t, code)
+
+	authCtx := login(ctx, t, st, email, password)
+	value := gofakeit.Sentence(5)
+	respSave, err := st.Client.SaveData(authCtx, &pb.SaveDataRequest{Data: []string{value}})
+	require.NoError(t, err)
+
+	newPassword := fakePassword()
+	respRecover, err := st.Client.RecoverAccount(ctx, &pb.RecoverAccountRequest{
+		Email:        email,
+		RecoveryCode: strings.ToLower(code),
+		NewPassword:  newPassword,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respRecover.GetRecoveryCode())
+	assert.NotEqual(t, code, respRecover.GetRecoveryCode())
+
+	// Старая сессия завершена
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Данные доступны после входа с новым паролем
+	newCtx := login(ctx, t, st, email, newPassword)
+	respGet, err := st.Client.GetData(newCtx, &pb.GetDataRequest{Id: respSave.GetIds()[0]})
+	require.NoError(t, err)
+	assert.Equal(t, value, respGet.GetRecord().GetSecret().GetText().GetText())
+
+	// Использованный код больше не действует
+	_, err = st.Client.RecoverAccount(ctx, &pb.RecoverAccountRequest{
+		Email:        email,
+		RecoveryCode: code,
+		NewPassword:  fakePassword(),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRecoverAccount_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, _ := registerNewUser(ctx, t, st)
+
+	// Код не был сгенерирован при регистрации
+	_, err := st.Client.RecoverAccount(ctx, &pb.RecoverAccountRequest{
+		Email:        email,
+		RecoveryCode: "AAAA-BBBB-CCCC-DDDD",
+		NewPassword:  fakePassword(),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.Client.RecoverAccount(ctx, &pb.RecoverAccountRequest{
+		Email:       email,
+		NewPassword: fakePassword(),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	require.NotEmpty(t, respLogin.GetRefreshToken())
+
+	respRefresh, err := st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{
+		RefreshToken: respLogin.GetRefreshToken(),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respRefresh.GetToken())
+	assert.NotEqual(t, respLogin.GetRefreshToken(), respRefresh.GetRefreshToken())
+
+	// Новый токен даёт доступ к данным
+	authCtx := suite.WithToken(ctx, respRefresh.GetToken())
+	_, err = st.Client.SaveData(authCtx, &pb.SaveDataRequest{Data: []string{"note"}})
+	require.NoError(t, err)
+
+	// Повторное использование токена завершает всю сессию
+	_, err = st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{
+		RefreshToken: respLogin.GetRefreshToken(),
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{
+		RefreshToken: respRefresh.GetRefreshToken(),
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestLogout(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, respLogin.GetToken())
+	otherCtx := login(ctx, t, st, email, password)
+
+	_, err = st.Client.Logout(authCtx, &pb.LogoutRequest{})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListData(authCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{
+		RefreshToken: respLogin.GetRefreshToken(),
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Другие сессии не затронуты
+	_, err = st.Client.ListData(otherCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+}
+
+func TestRefreshToken_Invalid(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "invalid"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+
+	respLaptop, err := st.Client.Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: password,
+		Device:   &pb.Device{Name: "laptop", ClientVersion: "1.2.0"},
+	})
+	require.NoError(t, err)
+	laptopCtx := suite.WithToken(ctx, respLaptop.GetToken())
+
+	respPhone, err := st.Client.Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: password,
+		Device:   &pb.Device{Name: "phone", ClientVersion: "1.1.0"},
+	})
+	require.NoError(t, err)
+	phoneCtx := suite.WithToken(ctx, respPhone.GetToken())
+
+	respList, err := st.Client.ListSessions(laptopCtx, &pb.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetSessions(), 2)
+
+	var phoneID string
+	for _, v := range respList.GetSessions() {
+		assert.NotEmpty(t, v.GetIp())
+		assert.NotEmpty(t, v.GetUserAgent())
+		switch v.GetDeviceName() {
+		case "laptop":
+			assert.True(t, v.GetCurrent())
+			assert.Equal(t, "1.2.0", v.GetClientVersion())
+		case "phone":
+			assert.False(t, v.GetCurrent())
+			phoneID = v.GetId()
+		}
+	}
+	require.NotEmpty(t, phoneID)
+
+	_, err = st.Client.RevokeSession(laptopCtx, &pb.RevokeSessionRequest{Id: phoneID})
+	require.NoError(t, err)
+
+	_, err = st.Client.ListData(phoneCtx, &pb.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.Client.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: respPhone.GetRefreshToken()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	respList, err = st.Client.ListSessions(laptopCtx, &pb.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetSessions(), 1)
+
+	_, err = st.Client.RevokeSession(laptopCtx, &pb.RevokeSessionRequest{Id: phoneID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSessions_RevokeOtherUserSession_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	ownerCtx := loginNewUser(ctx, t, st)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respList, err := st.Client.ListSessions(ownerCtx, &pb.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respList.GetSessions(), 1)
+
+	_, err = st.Client.RevokeSession(otherCtx, &pb.RevokeSessionRequest{Id: respList.GetSessions()[0].GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.ListData(ownerCtx, &pb.ListDataRequest{})
+	require.NoError(t, err)
+}
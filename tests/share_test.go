@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestShare_ReadOnly(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	recipientEmail, recipientPassword := registerNewUser(ctx, t, st)
+	recipientCtx := login(ctx, t, st, recipientEmail, recipientPassword)
+
+	username, password := gofakeit.Username(), fakePassword()
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{
+		Secrets:  []*pb.Secret{credentialsSecret(username, password)},
+		Metadata: map[string]string{"url": "postgres://db.example.com"},
+	})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	respShare, err := st.Client.ShareRecord(ownerCtx, &pb.ShareRecordRequest{
+		Id:             id,
+		RecipientEmail: recipientEmail,
+		Permission:     pb.Share_READ_ONLY,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, id, respShare.GetShare().GetRecordId())
+	assert.Equal(t, pb.Share_READ_ONLY, respShare.GetShare().GetPermission())
+
+	respShared, err := st.Client.ListSharedWithMe(recipientCtx, &pb.ListSharedWithMeRequest{})
+	require.NoError(t, err)
+	require.Len(t, respShared.GetRecords(), 1)
+	shared := respShared.GetRecords()[0]
+	assert.Equal(t, password, shared.GetRecord().GetSecret().GetCredentials().GetPassword())
+	assert.Equal(t, "postgres://db.example.com", shared.GetRecord().GetMetadata()["url"])
+	assert.NotEmpty(t, shared.GetShare().GetOwnerEmail())
+
+	// Чужие записи не появляются среди своих
+	_, err = st.Client.GetData(recipientCtx, &pb.GetDataRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.UpdateSharedRecord(recipientCtx, &pb.UpdateSharedRecordRequest{
+		Id:              id,
+		Secret:          credentialsSecret(username, fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.Client.RevokeShare(ownerCtx, &pb.RevokeShareRequest{Id: id, RecipientEmail: recipientEmail})
+	require.NoError(t, err)
+
+	respShared, err = st.Client.ListSharedWithMe(recipientCtx, &pb.ListSharedWithMeRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, respShared.GetRecords())
+
+	_, err = st.Client.RevokeShare(ownerCtx, &pb.RevokeShareRequest{Id: id, RecipientEmail: recipientEmail})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestShare_ReadWrite(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	recipientEmail, recipientPassword := registerNewUser(ctx, t, st)
+	recipientCtx := login(ctx, t, st, recipientEmail, recipientPassword)
+
+	username := gofakeit.Username()
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{
+		Secrets: []*pb.Secret{credentialsSecret(username, fakePassword())},
+	})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	_, err = st.Client.ShareRecord(ownerCtx, &pb.ShareRecordRequest{
+		Id:             id,
+		RecipientEmail: recipientEmail,
+		Permission:     pb.Share_READ_WRITE,
+	})
+	require.NoError(t, err)
+
+	newPassword := fakePassword()
+	respUpdate, err := st.Client.UpdateSharedRecord(recipientCtx, &pb.UpdateSharedRecordRequest{
+		Id:              id,
+		Secret:          credentialsSecret(username, newPassword),
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), respUpdate.GetRecord().GetVersion())
+
+	// Владелец видит изменение своим ключом
+	respGet, err := st.Client.GetData(ownerCtx, &pb.GetDataRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, newPassword, respGet.GetRecord().GetSecret().GetCredentials().GetPassword())
+
+	_, err = st.Client.UpdateSharedRecord(recipientCtx, &pb.UpdateSharedRecordRequest{
+		Id:              id,
+		Secret:          credentialsSecret(username, fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestShare_InvalidRecipient(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerEmail, ownerPassword := registerNewUser(ctx, t, st)
+	ownerCtx := login(ctx, t, st, ownerEmail, ownerPassword)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	_, err = st.Client.ShareRecord(ownerCtx, &pb.ShareRecordRequest{
+		Id:             id,
+		RecipientEmail: gofakeit.Email(),
+		Permission:     pb.Share_READ_ONLY,
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.ShareRecord(ownerCtx, &pb.ShareRecordRequest{
+		Id:             id,
+		RecipientEmail: ownerEmail,
+		Permission:     pb.Share_READ_ONLY,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Поделиться можно только своей записью
+	_, err = st.Client.ShareRecord(otherCtx, &pb.ShareRecordRequest{
+		Id:             id,
+		RecipientEmail: ownerEmail,
+		Permission:     pb.Share_READ_ONLY,
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
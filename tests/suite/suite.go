@@ -1,25 +1,35 @@
 package suite
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"strconv"
 	"testing"
 
+	"github.com/golang-jwt/jwt/v5"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
 
 	"github.com/gtngzlv/gophkeeper-server/internal/config"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/core"
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/mailer"
 )
 
 const grpcHost = "localhost"
 
 type Suite struct {
 	*testing.T
-	Cfg        *config.Config
-	AuthClient pb.AuthClient
+	Cfg    *config.Config
+	Client pb.GophkeeperClient
 }
 
 func New(t *testing.T) (context.Context, *Suite) {
@@ -42,12 +52,59 @@ func New(t *testing.T) (context.Context, *Suite) {
 	}
 
 	return ctx, &Suite{
-		T:          t,
-		Cfg:        cfg,
-		AuthClient: pb.NewAuthClient(cc),
+		T:      t,
+		Cfg:    cfg,
+		Client: pb.NewGophkeeperClient(cc),
 	}
 }
 
+// WithToken returns ctx with token attached as a bearer authorization header.
+func WithToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
 func grpcAddress(cfg *config.Config) string {
 	return net.JoinHostPort(grpcHost, strconv.Itoa(cfg.GRPC.Port))
 }
+
+// Keyfunc verifies tokens with the public keys published by the server JWKS endpoint.
+func (s *Suite) Keyfunc(token *jwt.Token) (interface{}, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/.well-known/jwks.json", net.JoinHostPort(grpcHost, strconv.Itoa(s.Cfg.REST.Port))))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var jwks core.JWKS
+	if err = json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, err
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, k := range jwks.Keys {
+		if k.KeyID == kid {
+			return k.PublicKey()
+		}
+	}
+	return nil, fmt.Errorf("key %q not published", kid)
+}
+
+// LastMail returns the last message sent to address by the file mailer configured for local runs.
+func (s *Suite) LastMail(address string) (mailer.Message, bool) {
+	s.Helper()
+
+	data, err := os.ReadFile(s.Cfg.Mail.File)
+	if err != nil {
+		s.Fatalf("failed to read mail file: %v", err)
+	}
+
+	var last mailer.Message
+	var found bool
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var msg mailer.Message
+		if json.Unmarshal(line, &msg) == nil && msg.To == address {
+			last, found = msg, true
+		}
+	}
+	return last, found
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestSync_Changes(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{gofakeit.Sentence(5), gofakeit.Sentence(5)},
+	})
+	require.NoError(t, err)
+	updatedID, deletedID := respSave.GetIds()[0], respSave.GetIds()[1]
+
+	respFull, err := st.Client.Sync(ctx, &pb.SyncRequest{})
+	require.NoError(t, err)
+	require.Len(t, respFull.GetRecords(), 2)
+	assert.Empty(t, respFull.GetDeleted())
+	assert.False(t, respFull.GetHasMore())
+	cursor := respFull.GetRevision()
+
+	respEmpty, err := st.Client.Sync(ctx, &pb.SyncRequest{SinceRevision: cursor})
+	require.NoError(t, err)
+	assert.Empty(t, respEmpty.GetRecords())
+	assert.Equal(t, cursor, respEmpty.GetRevision())
+
+	login := gofakeit.Username()
+	_, err = st.Client.UpdateData(ctx, &pb.UpdateDataRequest{
+		Id:              updatedID,
+		Secret:          credentialsSecret(login, fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+	_, err = st.Client.DeleteData(ctx, &pb.DeleteDataRequest{Id: deletedID, ExpectedVersion: 1})
+	require.NoError(t, err)
+
+	respDelta, err := st.Client.Sync(ctx, &pb.SyncRequest{SinceRevision: cursor})
+	require.NoError(t, err)
+	require.Len(t, respDelta.GetRecords(), 1)
+	assert.Equal(t, updatedID, respDelta.GetRecords()[0].GetId())
+	assert.Equal(t, login, respDelta.GetRecords()[0].GetSecret().GetCredentials().GetLogin())
+	require.Len(t, respDelta.GetDeleted(), 1)
+	assert.Equal(t, deletedID, respDelta.GetDeleted()[0].GetId())
+	assert.Greater(t, respDelta.GetRevision(), cursor)
+}
+
+func TestSync_Pagination(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	_, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{gofakeit.Sentence(5), gofakeit.Sentence(5), gofakeit.Sentence(5)},
+	})
+	require.NoError(t, err)
+
+	var (
+		cursor int64
+		ids    []int64
+	)
+	for {
+		resp, err := st.Client.Sync(ctx, &pb.SyncRequest{SinceRevision: cursor, PageSize: 2})
+		require.NoError(t, err)
+		for _, v := range resp.GetRecords() {
+			ids = append(ids, v.GetId())
+		}
+		cursor = resp.GetRevision()
+		if !resp.GetHasMore() {
+			break
+		}
+	}
+	assert.Len(t, ids, 3)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestTrash_DeleteAndRestore(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	text := gofakeit.Sentence(5)
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Data: []string{text},
+		Tags: []string{"notes"},
+	})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	respSync, err := st.Client.Sync(ctx, &pb.SyncRequest{})
+	require.NoError(t, err)
+	cursor := respSync.GetRevision()
+
+	_, err = st.Client.DeleteData(ctx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: 1})
+	require.NoError(t, err)
+
+	_, err = st.Client.GetData(ctx, &pb.GetDataRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	respTrash, err := st.Client.ListTrash(ctx, &pb.ListTrashRequest{})
+	require.NoError(t, err)
+	require.Len(t, respTrash.GetRecords(), 1)
+	trashed := respTrash.GetRecords()[0]
+	assert.Equal(t, id, trashed.GetId())
+	assert.Equal(t, text, trashed.GetSecret().GetText().GetText())
+	assert.NotNil(t, trashed.GetDeletedAt())
+
+	respRestore, err := st.Client.RestoreFromTrash(ctx, &pb.RestoreFromTrashRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, text, respRestore.GetRecord().GetSecret().GetText().GetText())
+	assert.Equal(t, []string{"notes"}, respRestore.GetRecord().GetTags())
+	assert.Nil(t, respRestore.GetRecord().GetDeletedAt())
+
+	respGet, err := st.Client.GetData(ctx, &pb.GetDataRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), respGet.GetRecord().GetVersion())
+
+	respTrash, err = st.Client.ListTrash(ctx, &pb.ListTrashRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, respTrash.GetRecords())
+
+	// Клиент, видевший удаление, получает запись снова
+	respDelta, err := st.Client.Sync(ctx, &pb.SyncRequest{SinceRevision: cursor})
+	require.NoError(t, err)
+	require.Len(t, respDelta.GetRecords(), 1)
+	assert.Equal(t, id, respDelta.GetRecords()[0].GetId())
+	assert.Empty(t, respDelta.GetDeleted())
+
+	_, err = st.Client.RestoreFromTrash(ctx, &pb.RestoreFromTrashRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTrash_OtherUser_NotFound(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	_, err = st.Client.DeleteData(ownerCtx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: 1})
+	require.NoError(t, err)
+
+	respTrash, err := st.Client.ListTrash(otherCtx, &pb.ListTrashRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, respTrash.GetRecords())
+
+	_, err = st.Client.RestoreFromTrash(otherCtx, &pb.RestoreFromTrashRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/lib/totp"
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestTwoFactor_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	authCtx := login(ctx, t, st, email, password)
+
+	respEnable, err := st.Client.Enable2FA(authCtx, &pb.Enable2FARequest{})
+	require.NoError(t, err)
+	assert.Contains(t, respEnable.GetUri(), "otpauth://totp/")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(respEnable.GetSecret())
+	require.NoError(t, err)
+
+	// Каждый код принимается один раз, поэтому используем соседние периоды
+	step := totp.Step(time.Now())
+	_, err = st.Client.Confirm2FA(authCtx, &pb.Confirm2FARequest{Code: totp.Code(secret, step-1)})
+	require.NoError(t, err)
+
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	assert.Empty(t, respLogin.GetToken())
+	require.NotEmpty(t, respLogin.GetChallengeToken())
+
+	respTwoFactor, err := st.Client.LoginTwoFactor(ctx, &pb.LoginTwoFactorRequest{
+		ChallengeToken: respLogin.GetChallengeToken(),
+		Code:           totp.Code(secret, step),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respTwoFactor.GetToken())
+	require.NotEmpty(t, respTwoFactor.GetRefreshToken())
+
+	_, err = st.Client.ListData(suite.WithToken(ctx, respTwoFactor.GetToken()), &pb.ListDataRequest{})
+	require.NoError(t, err)
+
+	// Повторно тот же код не принимается
+	respLogin, err = st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	_, err = st.Client.LoginTwoFactor(ctx, &pb.LoginTwoFactorRequest{
+		ChallengeToken: respLogin.GetChallengeToken(),
+		Code:           totp.Code(secret, step),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.Client.Disable2FA(authCtx, &pb.Disable2FARequest{Code: totp.Code(secret, step+1)})
+	require.NoError(t, err)
+
+	respLogin, err = st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	assert.NotEmpty(t, respLogin.GetToken())
+	assert.Empty(t, respLogin.GetChallengeToken())
+}
+
+func TestTwoFactor_WrongCode_ChallengeRevoked(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	authCtx := login(ctx, t, st, email, password)
+
+	respEnable, err := st.Client.Enable2FA(authCtx, &pb.Enable2FARequest{})
+	require.NoError(t, err)
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(respEnable.GetSecret())
+	require.NoError(t, err)
+
+	step := totp.Step(time.Now())
+	_, err = st.Client.Confirm2FA(authCtx, &pb.Confirm2FARequest{Code: "000000"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.Client.Confirm2FA(authCtx, &pb.Confirm2FARequest{Code: totp.Code(secret, step-1)})
+	require.NoError(t, err)
+
+	_, err = st.Client.Enable2FA(authCtx, &pb.Enable2FARequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	respLogin, err := st.Client.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	require.NoError(t, err)
+
+	wrong := "000000"
+	if totp.Code(secret, step) == wrong {
+		wrong = "111111"
+	}
+	_, err = st.Client.LoginTwoFactor(ctx, &pb.LoginTwoFactorRequest{
+		ChallengeToken: respLogin.GetChallengeToken(),
+		Code:           wrong,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Неверный код завершает challenge
+	_, err = st.Client.LoginTwoFactor(ctx, &pb.LoginTwoFactorRequest{
+		ChallengeToken: respLogin.GetChallengeToken(),
+		Code:           totp.Code(secret, step),
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestVerifyEmail_Success(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	code := verificationCode(t, st, email)
+
+	_, err := st.Client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Code: code})
+	require.NoError(t, err)
+
+	// Код одноразовый
+	_, err = st.Client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Code: code})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	authCtx := login(ctx, t, st, email, password)
+	_, err = st.Client.SendVerificationEmail(authCtx, &pb.SendVerificationEmailRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestVerifyEmail_Resend(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email, password := registerNewUser(ctx, t, st)
+	first := verificationCode(t, st, email)
+
+	authCtx := login(ctx, t, st, email, password)
+	_, err := st.Client.SendVerificationEmail(authCtx, &pb.SendVerificationEmailRequest{})
+	require.NoError(t, err)
+
+	second := verificationCode(t, st, email)
+	_, err = st.Client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Code: second})
+	require.NoError(t, err)
+
+	_, err = st.Client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Code: first})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestVerifyEmail_InvalidCode_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Code: gofakeit.UUID()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRegister_InvalidEmail_Failed(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Client.Register(ctx, &pb.RegisterRequest{
+		Email:    "John <" + gofakeit.Email() + ">",
+		Password: fakePassword(),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// verificationCode returns the code from the last verification email sent to email.
+func verificationCode(t *testing.T, st *suite.Suite, email string) string {
+	t.Helper()
+
+	msg, ok := st.LastMail(email)
+	require.True(t, ok, "verification email is not sent")
+	for _, line := range strings.Split(msg.Body, "\n") {
+		// Код - единственная строка без пробелов
+		if line = strings.TrimSpace(line); line != "" && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	t.Fatalf("code not found in %q", msg.Body)
+	return ""
+}
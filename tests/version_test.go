@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestRecordVersions_ListAndRestore(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	login, oldPassword := gofakeit.Username(), fakePassword()
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{
+		Secrets:  []*pb.Secret{credentialsSecret(login, oldPassword)},
+		Metadata: map[string]string{"url": "https://old.example.com"},
+		Tags:     []string{"work"},
+	})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	// Пароль затёрли по ошибке
+	respUpdate, err := st.Client.UpdateData(ctx, &pb.UpdateDataRequest{
+		Id:              id,
+		Secret:          credentialsSecret(login, fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+
+	respVersions, err := st.Client.ListRecordVersions(ctx, &pb.ListRecordVersionsRequest{Id: id})
+	require.NoError(t, err)
+	require.Len(t, respVersions.GetVersions(), 1)
+	first := respVersions.GetVersions()[0]
+	assert.Equal(t, int64(1), first.GetVersion())
+	assert.Equal(t, oldPassword, first.GetSecret().GetCredentials().GetPassword())
+	assert.Equal(t, "https://old.example.com", first.GetMetadata()["url"])
+	assert.Equal(t, []string{"work"}, first.GetTags())
+
+	_, err = st.Client.RestoreRecordVersion(ctx, &pb.RestoreRecordVersionRequest{Id: id, Version: 1, ExpectedVersion: 1})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	respRestore, err := st.Client.RestoreRecordVersion(ctx, &pb.RestoreRecordVersionRequest{
+		Id:              id,
+		Version:         1,
+		ExpectedVersion: respUpdate.GetRecord().GetVersion(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), respRestore.GetRecord().GetVersion())
+	assert.Equal(t, oldPassword, respRestore.GetRecord().GetSecret().GetCredentials().GetPassword())
+	assert.Equal(t, "https://old.example.com", respRestore.GetRecord().GetMetadata()["url"])
+
+	// Перезаписанная версия тоже сохранилась
+	respVersions, err = st.Client.ListRecordVersions(ctx, &pb.ListRecordVersionsRequest{Id: id})
+	require.NoError(t, err)
+	require.Len(t, respVersions.GetVersions(), 2)
+	assert.Equal(t, int64(2), respVersions.GetVersions()[0].GetVersion())
+}
+
+func TestRecordVersions_OtherUser_NotFound(t *testing.T) {
+	ctx, st := suite.New(t)
+	ownerCtx := loginNewUser(ctx, t, st)
+	otherCtx := loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ownerCtx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	_, err = st.Client.ListRecordVersions(otherCtx, &pb.ListRecordVersionsRequest{Id: id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.Client.RestoreRecordVersion(ownerCtx, &pb.RestoreRecordVersionRequest{Id: id, Version: 5, ExpectedVersion: 1})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gtngzlv/gophkeeper-server/internal/proto/pb"
+
+	"github.com/gtngzlv/gophkeeper-server/tests/suite"
+)
+
+func TestWatchChanges_LiveAndResume(t *testing.T) {
+	ctx, st := suite.New(t)
+	ctx = loginNewUser(ctx, t, st)
+
+	respSave, err := st.Client.SaveData(ctx, &pb.SaveDataRequest{Data: []string{gofakeit.Sentence(5)}})
+	require.NoError(t, err)
+	id := respSave.GetIds()[0]
+
+	watchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	stream, err := st.Client.WatchChanges(watchCtx, &pb.WatchChangesRequest{})
+	require.NoError(t, err)
+
+	// Сначала приходят изменения до подписки
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.ChangeEvent_CREATED, event.GetType())
+	assert.Equal(t, id, event.GetRecord().GetId())
+	cursor := event.GetRevision()
+
+	_, err = st.Client.UpdateData(ctx, &pb.UpdateDataRequest{
+		Id:              id,
+		Secret:          credentialsSecret(gofakeit.Username(), fakePassword()),
+		ExpectedVersion: 1,
+	})
+	require.NoError(t, err)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.ChangeEvent_UPDATED, event.GetType())
+	assert.Equal(t, int64(2), event.GetRecord().GetVersion())
+	cancel()
+
+	// Изменение, пропущенное без подключения, приходит после переподключения
+	_, err = st.Client.DeleteData(ctx, &pb.DeleteDataRequest{Id: id, ExpectedVersion: 2})
+	require.NoError(t, err)
+
+	resumeCtx, resumeCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer resumeCancel()
+	stream, err = st.Client.WatchChanges(resumeCtx, &pb.WatchChangesRequest{SinceRevision: cursor})
+	require.NoError(t, err)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.ChangeEvent_UPDATED, event.GetType())
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.ChangeEvent_DELETED, event.GetType())
+	assert.Equal(t, id, event.GetDeleted().GetId())
+}